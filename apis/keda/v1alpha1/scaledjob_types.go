@@ -55,6 +55,8 @@ type ScaledJobSpec struct {
 	// +optional
 	MaxReplicaCount *int32 `json:"maxReplicaCount,omitempty"`
 	// +optional
+	MinReplicaCount *int32 `json:"minReplicaCount,omitempty"`
+	// +optional
 	ScalingStrategy ScalingStrategy `json:"scalingStrategy,omitempty"`
 	Triggers        []ScaleTriggers `json:"triggers"`
 }
@@ -89,6 +91,13 @@ type ScalingStrategy struct {
 	PendingPodConditions []string `json:"pendingPodConditions,omitempty"`
 	// +optional
 	MultipleScalersCalculation string `json:"multipleScalersCalculation,omitempty"`
+	// +optional
+	IncludeInactiveInAverage bool `json:"includeInactiveInAverage,omitempty"`
+	// +optional
+	// +kubebuilder:validation:Enum=ceil;floor;round
+	ScalingRoundingMode string `json:"scalingRoundingMode,omitempty"`
+	// +optional
+	ActivationQueueLength int64 `json:"activationQueueLength,omitempty"`
 }
 
 func init() {
@@ -103,3 +112,12 @@ func (s ScaledJob) MaxReplicaCount() int64 {
 
 	return 100
 }
+
+// MinReplicaCount returns MinReplicaCount
+func (s ScaledJob) MinReplicaCount() int64 {
+	if s.Spec.MinReplicaCount != nil {
+		return int64(*s.Spec.MinReplicaCount)
+	}
+
+	return 0
+}
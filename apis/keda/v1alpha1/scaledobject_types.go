@@ -98,6 +98,8 @@ type AdvancedConfig struct {
 	HorizontalPodAutoscalerConfig *HorizontalPodAutoscalerConfig `json:"horizontalPodAutoscalerConfig,omitempty"`
 	// +optional
 	RestoreToOriginalReplicaCount bool `json:"restoreToOriginalReplicaCount,omitempty"`
+	// +optional
+	EvaluateAllTriggers bool `json:"evaluateAllTriggers,omitempty"`
 }
 
 // HorizontalPodAutoscalerConfig specifies horizontal scale config
@@ -39,7 +39,7 @@ func (p *KedaProvider) getMetricsWithFallback(scaler scalers.Scaler, metricName
 	status := scaledObject.Status.DeepCopy()
 
 	initHealthStatus(status)
-	metrics, err := scaler.GetMetrics(context.TODO(), metricName, metricSelector)
+	metrics, err := p.getScalerMetrics(context.TODO(), scaler, metricName, metricSelector)
 	healthStatus := getHealthStatus(status, metricName)
 
 	if err == nil {
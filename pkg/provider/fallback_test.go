@@ -17,9 +17,11 @@ limitations under the License.
 package provider
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/golang/mock/gomock"
@@ -29,6 +31,7 @@ import (
 	"k8s.io/api/autoscaling/v2beta2"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/metrics/pkg/apis/external_metrics"
 	"sigs.k8s.io/controller-runtime/pkg/envtest/printer"
 	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider"
@@ -196,6 +199,55 @@ var _ = Describe("fallback", func() {
 		Expect(so.Status.Health[metricName]).To(haveFailureAndStatus(4, kedav1alpha1.HealthStatusFailing))
 	})
 
+	It("should fall back when the scaler does not return its metrics within the scaler timeout", func() {
+		providerUnderTest.scalerTimeout = 50 * time.Millisecond
+		returned := make(chan struct{})
+		scaler.EXPECT().GetMetrics(gomock.Any(), gomock.Eq(metricName), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, metricName string, metricSelector labels.Selector) ([]external_metrics.ExternalMetricValue, error) {
+				<-ctx.Done()
+				close(returned)
+				return nil, ctx.Err()
+			})
+		closedAfterReturn := make(chan bool, 1)
+		scaler.EXPECT().Close(gomock.Any()).DoAndReturn(func(context.Context) error {
+			select {
+			case <-returned:
+				closedAfterReturn <- true
+			default:
+				closedAfterReturn <- false
+			}
+			return nil
+		})
+		startingNumberOfFailures := int32(3)
+		expectedMetricValue := int64(100)
+
+		so := buildScaledObject(
+			&kedav1alpha1.Fallback{
+				FailureThreshold: int32(3),
+				Replicas:         int32(10),
+			},
+			&kedav1alpha1.ScaledObjectStatus{
+				Health: map[string]kedav1alpha1.HealthStatus{
+					metricName: {
+						NumberOfFailures: &startingNumberOfFailures,
+						Status:           kedav1alpha1.HealthStatusHappy,
+					},
+				},
+			},
+		)
+		metricSpec := createMetricSpec(10)
+		expectStatusPatch(ctrl, client)
+
+		metrics, err := providerUnderTest.getMetricsWithFallback(scaler, metricName, nil, so, metricSpec)
+		providerUnderTest.closeScaler(context.Background(), scaler)
+
+		Expect(err).ToNot(HaveOccurred())
+		value, _ := metrics[0].Value.AsInt64()
+		Expect(value).Should(Equal(expectedMetricValue))
+		Expect(so.Status.Health[metricName]).To(haveFailureAndStatus(4, kedav1alpha1.HealthStatusFailing))
+		Eventually(closedAfterReturn).Should(Receive(BeTrue()))
+	})
+
 	It("should behave as if fallback is disabled when the metrics spec target type is not average value metric", func() {
 		so := buildScaledObject(
 			&kedav1alpha1.Fallback{
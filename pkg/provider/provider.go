@@ -20,6 +20,8 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-logr/logr"
 	apiErrors "k8s.io/apimachinery/pkg/api/errors"
@@ -32,6 +34,7 @@ import (
 
 	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
 	prommetrics "github.com/kedacore/keda/v2/pkg/metrics"
+	"github.com/kedacore/keda/v2/pkg/scalers"
 	"github.com/kedacore/keda/v2/pkg/scaling"
 )
 
@@ -42,6 +45,15 @@ type KedaProvider struct {
 	externalMetrics  []externalMetric
 	scaleHandler     scaling.ScaleHandler
 	watchedNamespace string
+
+	// scalerTimeout bounds each GetMetrics call so a hung scaler doesn't block the
+	// metrics request, it is disabled when 0
+	scalerTimeout time.Duration
+
+	// pendingCalls holds the GetMetrics calls that are still running past their
+	// timeout, so closeScaler can wait for them before closing the scaler
+	pendingCallsLock sync.Mutex
+	pendingCalls     map[scalers.Scaler][]<-chan struct{}
 }
 
 type externalMetric struct{}
@@ -57,6 +69,7 @@ func NewProvider(adapterLogger logr.Logger, scaleHandler scaling.ScaleHandler, c
 		client:           client,
 		scaleHandler:     scaleHandler,
 		watchedNamespace: watchedNamespace,
+		scalerTimeout:    scaling.GetScalerTimeout(adapterLogger),
 	}
 	logger = adapterLogger.WithName("provider")
 	logger.Info("starting")
@@ -124,7 +137,7 @@ func (p *KedaProvider) GetExternalMetric(ctx context.Context, namespace string,
 				metricsServer.RecordHPAScalerError(namespace, scaledObject.Name, scalerName, scalerIndex, info.Metric, err)
 			}
 		}
-		scaler.Close(ctx)
+		p.closeScaler(ctx, scaler)
 	}
 
 	if len(matchingMetrics) == 0 {
@@ -136,6 +149,66 @@ func (p *KedaProvider) GetExternalMetric(ctx context.Context, namespace string,
 	}, nil
 }
 
+// getScalerMetrics returns an error when the scaler doesn't return its metrics within
+// scalerTimeout, even if it ignores the cancellation of its context
+func (p *KedaProvider) getScalerMetrics(ctx context.Context, scaler scalers.Scaler, metricName string, metricSelector labels.Selector) ([]external_metrics.ExternalMetricValue, error) {
+	if p.scalerTimeout <= 0 {
+		return scaler.GetMetrics(ctx, metricName, metricSelector)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, p.scalerTimeout)
+	defer cancel()
+
+	type result struct {
+		metrics []external_metrics.ExternalMetricValue
+		err     error
+	}
+	done := make(chan result, 1)
+	returned := make(chan struct{})
+	go func() {
+		metrics, err := scaler.GetMetrics(timeoutCtx, metricName, metricSelector)
+		close(returned)
+		done <- result{metrics, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.metrics, r.err
+	case <-timeoutCtx.Done():
+		p.pendingCallsLock.Lock()
+		if p.pendingCalls == nil {
+			p.pendingCalls = map[scalers.Scaler][]<-chan struct{}{}
+		}
+		p.pendingCalls[scaler] = append(p.pendingCalls[scaler], returned)
+		p.pendingCallsLock.Unlock()
+
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("metrics request was interrupted: %w", err)
+		}
+		return nil, fmt.Errorf("scaler did not return its metrics within %s: %w", p.scalerTimeout, timeoutCtx.Err())
+	}
+}
+
+// closeScaler closes the scaler, in the background once its timed out GetMetrics calls
+// have returned if there are any
+func (p *KedaProvider) closeScaler(ctx context.Context, scaler scalers.Scaler) {
+	p.pendingCallsLock.Lock()
+	pending := p.pendingCalls[scaler]
+	delete(p.pendingCalls, scaler)
+	p.pendingCallsLock.Unlock()
+
+	if len(pending) == 0 {
+		scaler.Close(ctx)
+		return
+	}
+	go func() {
+		for _, returned := range pending {
+			<-returned
+		}
+		scaler.Close(ctx)
+	}()
+}
+
 // ListAllExternalMetrics returns the supported external metrics for this provider
 func (p *KedaProvider) ListAllExternalMetrics() []provider.ExternalMetricInfo {
 	externalMetricsInfo := []provider.ExternalMetricInfo{}
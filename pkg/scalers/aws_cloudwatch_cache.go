@@ -0,0 +1,102 @@
+package scalers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+// cloudwatchQueryCache shares GetMetricData results between the scalers of the process,
+// so ScaledObjects polling the same metric within the TTL make a single API call
+type cloudwatchQueryCache struct {
+	mutex   sync.Mutex
+	entries map[string]*cloudwatchQueryCacheEntry
+}
+
+type cloudwatchQueryCacheEntry struct {
+	// done is closed once output and err are set, concurrent identical queries wait for it
+	done    chan struct{}
+	output  *cloudwatch.GetMetricDataOutput
+	err     error
+	expires time.Time
+	// canceled is set when the call failed because the context of the fetching caller was done,
+	// the waiting callers then fetch again with their own context instead of sharing the error
+	canceled bool
+}
+
+var sharedCloudwatchQueryCache = &cloudwatchQueryCache{entries: map[string]*cloudwatchQueryCacheEntry{}}
+
+// get returns the cached output for key or calls fetch with the context of the caller, failed calls are not cached
+func (q *cloudwatchQueryCache) get(ctx context.Context, key string, ttl time.Duration, fetch func(context.Context) (*cloudwatch.GetMetricDataOutput, error)) (*cloudwatch.GetMetricDataOutput, error) {
+	for {
+		output, canceled, err := q.getOnce(ctx, key, ttl, fetch)
+		if !canceled || ctx.Err() != nil {
+			return output, err
+		}
+	}
+}
+
+// getOnce waits for an identical call in progress or makes the call, canceled reports that the call
+// in progress was abandoned by its caller
+func (q *cloudwatchQueryCache) getOnce(ctx context.Context, key string, ttl time.Duration, fetch func(context.Context) (*cloudwatch.GetMetricDataOutput, error)) (*cloudwatch.GetMetricDataOutput, bool, error) {
+	now := time.Now()
+
+	q.mutex.Lock()
+	for k, entry := range q.entries {
+		if isClosed(entry.done) && now.After(entry.expires) {
+			delete(q.entries, k)
+		}
+	}
+	entry, ok := q.entries[key]
+	if !ok {
+		entry = &cloudwatchQueryCacheEntry{done: make(chan struct{})}
+		q.entries[key] = entry
+	}
+	q.mutex.Unlock()
+
+	if ok {
+		select {
+		case <-entry.done:
+			return entry.output, entry.canceled, entry.err
+		case <-ctx.Done():
+			return nil, false, ctx.Err()
+		}
+	}
+
+	entry.output, entry.err = fetch(ctx)
+	entry.expires = time.Now().Add(ttl)
+	entry.canceled = entry.err != nil && ctx.Err() != nil
+	if entry.err != nil {
+		q.mutex.Lock()
+		delete(q.entries, key)
+		q.mutex.Unlock()
+	}
+	close(entry.done)
+	return entry.output, false, entry.err
+}
+
+func isClosed(done chan struct{}) bool {
+	select {
+	case <-done:
+		return true
+	default:
+		return false
+	}
+}
+
+// getCloudwatchQueryCacheKey identifies a query by everything that changes its result: the account
+// it is sent to, the queries and the window, whose end is bucketed by the TTL
+func getCloudwatchQueryCacheKey(region string, meta *awsCloudwatchMetadata, input *cloudwatch.GetMetricDataInput, ttl time.Duration) string {
+	auth := meta.awsAuthorization
+	window := input.EndTime.Sub(*input.StartTime)
+	key := fmt.Sprintf("%s|%s|%t|%s|%s|%s|%t|%s|%d|%v",
+		region, meta.awsEndpoint, meta.useFIPS, auth.awsRoleArn, auth.awsRoleExternalID, auth.awsAccessKeyID, auth.podIdentityOwner,
+		window, input.EndTime.Truncate(ttl).Unix(), input.MetricDataQueries)
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
@@ -2,14 +2,25 @@ package scalers
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/cloudwatch"
 	"k8s.io/api/autoscaling/v2beta2"
@@ -27,10 +38,58 @@ const (
 	defaultMetricCollectionTime = 300
 	defaultMetricStat           = "Average"
 	defaultMetricStatPeriod     = 300
+	defaultIgnoreNullValues     = true
+
+	defaultMaxMetricCollectionTime = 86400
+
+	// cloudwatchSampleCountQueryID is the id of the SampleCount query added for minSampleCount
+	cloudwatchSampleCountQueryID = "sc"
+)
+
+var (
+	cloudwatchStatistics = []string{"Average", "Sum", "Minimum", "Maximum", "SampleCount"}
+
+	// cloudwatchInsightRuleMetrics are the metrics a Contributor Insights rule report can return
+	cloudwatchInsightRuleMetrics = []string{"UniqueContributors", "MaxContributorValue", "SampleCount", "Sum", "Minimum", "Maximum", "Average"}
+
+	cloudwatchPercentileStatistic = regexp.MustCompile(`^p\d{1,2}(\.\d{1,2})?$`)
+
+	// awsRegionFormat matches regions like eu-west-1, including the gov, iso and cn partitions
+	awsRegionFormat = regexp.MustCompile(`^[a-z]{2}(-gov|-iso[a-z]?)?-[a-z]+-\d+$`)
+
+	awsAccountIDFormat = regexp.MustCompile(`^\d{12}$`)
 )
 
 type awsCloudwatchScaler struct {
 	metadata *awsCloudwatchMetadata
+	// cwClients holds a client for each of the awsRegions
+	cwClients  []cloudwatchClient
+	httpClient *http.Client
+
+	// activeValue is the value fetched by IsActive, it is used once by the following
+	// GetMetrics call, e.g. when a ScaledJob is polled, so CloudWatch isn't queried twice
+	activeValueLock sync.Mutex
+	activeValue     *cloudwatchValue
+
+	// clock returns the time the query window ends at, before metricEndTimeOffset, it is time.Now by default
+	clock func() time.Time
+}
+
+// cloudwatchValue is the metric value with what CloudWatch returned alongside it,
+// messages are e.g. warnings about partial data
+type cloudwatchValue struct {
+	value     float64
+	timestamp time.Time
+	unit      string
+	messages  []string
+}
+
+// cloudwatchClient is the part of the CloudWatch API used by the scaler,
+// it is implemented by *cloudwatch.CloudWatch and can be faked in tests
+type cloudwatchClient interface {
+	GetMetricDataWithContext(ctx aws.Context, input *cloudwatch.GetMetricDataInput, opts ...request.Option) (*cloudwatch.GetMetricDataOutput, error)
+	DescribeAlarmsWithContext(ctx aws.Context, input *cloudwatch.DescribeAlarmsInput, opts ...request.Option) (*cloudwatch.DescribeAlarmsOutput, error)
+	GetInsightRuleReportWithContext(ctx aws.Context, input *cloudwatch.GetInsightRuleReportInput, opts ...request.Option) (*cloudwatch.GetInsightRuleReportOutput, error)
 }
 
 type awsCloudwatchMetadata struct {
@@ -39,15 +98,107 @@ type awsCloudwatchMetadata struct {
 	dimensionName  []string
 	dimensionValue []string
 
+	// expression is a CloudWatch Metric Math or Metrics Insights expression,
+	// when it is given namespace, dimensionName and dimensionValue are optional
+	// and metricName is only used to generate the metric name
+	expression string
+
+	// queries is a JSON array of CloudWatch MetricDataQuery,
+	// the same optional fields as for expression apply
+	queries []*cloudwatch.MetricDataQuery
+
+	// metricAggregation is how the datapoints of a result are combined, last (default) uses
+	// the newest one while sum adds up every metricStatPeriod datapoint within metricCollectionTime,
+	// e.g. the total over 300s for a Sum metricStat with a 60s period
+	metricAggregation string
+
+	// anomalyDetectionBandID is the id of an ANOMALY_DETECTION_BAND query in queries, when it is
+	// given IsActive compares the metric value against the upper band instead of activationTargetValue
+	// and the band is left out of the reported metric value
+	anomalyDetectionBandID string
+
+	// metricResultAggregation is how the newest values of multiple results are combined,
+	// one of first, max, sum or avg. It defaults to max for queries and first otherwise
+	metricResultAggregation string
+
+	// alarmName is a metric or composite alarm used instead of a metric query,
+	// the scaler reports 1 while the alarm is in ALARM state and 0 otherwise
+	alarmName string
+
+	// insightRuleName is a Contributor Insights rule used instead of a metric query, the scaler
+	// reports the newest insightRuleMetric datapoint of its report, UniqueContributors by default
+	insightRuleName   string
+	insightRuleMetric string
+
+	// denominatorMetricName is a second metric the first one is divided by, e.g. the
+	// backlog per consumer. The namespace defaults to the one of the first metric
+	denominatorNamespace      string
+	denominatorMetricName     string
+	denominatorDimensionName  []string
+	denominatorDimensionValue []string
+	// zeroDenominatorBehavior is zero to report 0 when the denominator is 0,
+	// or numerator to report the first metric as if it was divided by 1
+	zeroDenominatorBehavior string
+
+	// externalMetricName replaces the generated name of the metric exposed to the HPA
+	externalMetricName string
+
 	targetMetricValue float64
 	minMetricValue    float64
 
+	// metricTargetType is Value to compare targetMetricValue with the metric value as is,
+	// or AverageValue (the default) to compare it with the value divided by the replicas
+	metricTargetType v2beta2.MetricTargetType
+
+	// activationTargetValue is only used by IsActive to scale from zero, it defaults to
+	// minMetricValue so existing triggers keep activating at the same threshold
+	activationTargetValue float64
+
 	metricCollectionTime int64
 	metricStat           string
 	metricStatPeriod     int64
+	metricUnit           string
+	metricEndTimeOffset  int64
+
+	// metricStats are all the stats of a comma separated metricStat, metricStat is the first one.
+	// Each stat is queried for the same metric and the values are combined with
+	// metricStatAggregation (max, min or avg, default max)
+	metricStats           []string
+	metricStatAggregation string
+
+	// minSampleCount is the number of datapoints the metric needs within the window for its
+	// value to be used, minMetricValue is reported below it. It is disabled when 0
+	minSampleCount int64
+
+	// softErrorCodes are AWS error codes like Throttling for which minMetricValue is
+	// reported instead of failing the scaler
+	softErrorCodes []string
+
+	// ignoreNullValues reports minMetricValue when no datapoints are received
+	// instead of returning an error, it defaults to true
+	ignoreNullValues bool
 
 	awsRegion string
 
+	// awsRegions are all the regions queried when awsRegion is a comma separated list,
+	// their values are combined with regionAggregation (sum, avg or max, default sum)
+	awsRegions        []string
+	regionAggregation string
+
+	// awsAccountID is the source account of the metric when querying a monitoring
+	// account with CloudWatch cross-account observability
+	awsAccountID string
+
+	// awsEndpoint overrides the CloudWatch endpoint, e.g. for LocalStack
+	awsEndpoint string
+
+	// queryCacheTTL shares the results of identical queries of all the triggers in
+	// the process for this long, it is disabled by default
+	queryCacheTTL time.Duration
+
+	// useFIPS sends the requests to the FIPS endpoint of the region, e.g. monitoring-fips.us-east-1.amazonaws.com
+	useFIPS bool
+
 	awsAuthorization awsAuthorizationMetadata
 
 	scalerIndex int
@@ -62,78 +213,337 @@ func NewAwsCloudwatchScaler(config *ScalerConfig) (Scaler, error) {
 		return nil, fmt.Errorf("error parsing cloudwatch metadata: %s", err)
 	}
 
+	httpClient := kedautil.CreateHTTPClient(config.GlobalHTTPTimeout)
+	cwClients, err := createCloudwatchClients(meta, httpClient)
+	if err != nil {
+		return nil, fmt.Errorf("error creating cloudwatch client: %s", err)
+	}
+
 	return &awsCloudwatchScaler{
-		metadata: meta,
+		metadata:   meta,
+		cwClients:  cwClients,
+		httpClient: httpClient,
+		clock:      time.Now,
 	}, nil
 }
 
-func parseMetricValues(config *ScalerConfig) (*awsCloudwatchMetadata, error) {
-	metricsMeta := awsCloudwatchMetadata{}
+// createCloudwatchClients creates the clients once per scaler, the credentials
+// (including the assumed role ones) are cached and refreshed by the SDK and
+// shared by the clients of all the regions
+func createCloudwatchClients(metadata *awsCloudwatchMetadata, httpClient *http.Client) ([]cloudwatchClient, error) {
+	sess, err := session.NewSession(&aws.Config{
+		Region:     aws.String(metadata.awsRegion),
+		HTTPClient: httpClient,
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	if val, ok := config.TriggerMetadata["metricCollectionTime"]; ok && val != "" {
-		if n, ok := strconv.ParseInt(val, 10, 64); ok == nil {
-			metricsMeta.metricCollectionTime = n
-		} else {
-			return nil, fmt.Errorf("metricCollectionTime not a valid number")
+	creds := getCloudwatchCredentials(sess, metadata.awsAuthorization)
+
+	cwClients := make([]cloudwatchClient, 0, len(metadata.awsRegions))
+	for _, region := range metadata.awsRegions {
+		cfg := &aws.Config{
+			Region:      aws.String(region),
+			HTTPClient:  httpClient,
+			Credentials: creds,
 		}
-	} else {
-		metricsMeta.metricCollectionTime = defaultMetricCollectionTime
+		if metadata.awsEndpoint != "" {
+			cfg.Endpoint = aws.String(metadata.awsEndpoint)
+		}
+		if metadata.useFIPS {
+			if endpoint, ok := getCloudwatchFIPSEndpoint(region); ok {
+				cfg.Endpoint = aws.String(endpoint)
+			} else {
+				cloudwatchLog.Info("No FIPS endpoint known for the region, using the default endpoint", "awsRegion", region)
+			}
+		}
+		cwClients = append(cwClients, cloudwatch.New(sess, cfg))
 	}
+	return cwClients, nil
+}
 
-	if val, ok := config.TriggerMetadata["metricStatPeriod"]; ok && val != "" {
-		if n, ok := strconv.ParseInt(val, 10, 64); ok == nil {
-			metricsMeta.metricStatPeriod = n
-		} else {
-			return nil, fmt.Errorf("metricStatPeriod not a valid number")
+// getCloudwatchCredentials returns nil when the session default credential chain should be used
+func getCloudwatchCredentials(sess *session.Session, auth awsAuthorizationMetadata) *credentials.Credentials {
+	if auth.podIdentityOwner {
+		if auth.awsRoleArn != "" {
+			return stscreds.NewCredentials(sess, auth.awsRoleArn, getAssumeRoleOptions(auth)...)
 		}
-	} else {
-		metricsMeta.metricStatPeriod = defaultMetricStatPeriod
+		return credentials.NewStaticCredentials(auth.awsAccessKeyID, auth.awsSecretAccessKey, "")
+	}
+
+	// EKS IAM roles for service accounts (IRSA) inject the role and the web identity token file
+	roleArn := os.Getenv("AWS_ROLE_ARN")
+	tokenFile := os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE")
+	if roleArn != "" && tokenFile != "" {
+		return stscreds.NewWebIdentityCredentials(sess, roleArn, os.Getenv("AWS_ROLE_SESSION_NAME"), tokenFile)
 	}
 
+	return nil
+}
+
+// getCloudwatchFIPSEndpoint looks up the FIPS endpoint, the SDK lists them as fips- prefixed regions
+func getCloudwatchFIPSEndpoint(region string) (string, bool) {
+	endpoint, err := endpoints.DefaultResolver().EndpointFor(cloudwatch.EndpointsID, "fips-"+region, endpoints.StrictMatchingOption)
+	if err != nil {
+		return "", false
+	}
+	return endpoint.URL, true
+}
+
+// getAssumeRoleOptions sets the optional external ID required by some cross-account roles
+func getAssumeRoleOptions(auth awsAuthorizationMetadata) []func(*stscreds.AssumeRoleProvider) {
+	var options []func(*stscreds.AssumeRoleProvider)
+	if auth.awsRoleExternalID != "" {
+		options = append(options, func(p *stscreds.AssumeRoleProvider) {
+			p.ExternalID = aws.String(auth.awsRoleExternalID)
+		})
+	}
+	return options
+}
+
+func parseMetricValues(config *ScalerConfig) (*awsCloudwatchMetadata, error) {
+	metricsMeta := awsCloudwatchMetadata{}
+
+	metricCollectionTime, err := parseCloudwatchSeconds(config, "metricCollectionTime", defaultMetricCollectionTime)
+	if err != nil {
+		return nil, err
+	}
+	metricsMeta.metricCollectionTime = metricCollectionTime
+
+	metricStatPeriod, err := parseCloudwatchSeconds(config, "metricStatPeriod", defaultMetricStatPeriod)
+	if err != nil {
+		return nil, err
+	}
+	metricsMeta.metricStatPeriod = metricStatPeriod
+
 	if val, ok := config.TriggerMetadata["metricStat"]; ok && val != "" {
-		metricsMeta.metricStat = val
+		for _, stat := range strings.Split(val, ",") {
+			metricsMeta.metricStats = append(metricsMeta.metricStats, strings.TrimSpace(stat))
+		}
 	} else {
-		metricsMeta.metricStat = defaultMetricStat
+		metricsMeta.metricStats = []string{defaultMetricStat}
 	}
+	metricsMeta.metricStat = metricsMeta.metricStats[0]
 
 	return &metricsMeta, nil
 }
 
+// parseCloudwatchSeconds reads a setting given either in seconds, e.g. metricStatPeriod: "300",
+// or as a duration string with the Duration suffix, e.g. metricStatPeriodDuration: "5m"
+func parseCloudwatchSeconds(config *ScalerConfig, name string, defaultValue int64) (int64, error) {
+	seconds, hasSeconds := config.TriggerMetadata[name]
+	hasSeconds = hasSeconds && seconds != ""
+	duration, hasDuration := config.TriggerMetadata[name+"Duration"]
+	hasDuration = hasDuration && duration != ""
+
+	switch {
+	case hasSeconds && hasDuration:
+		return 0, fmt.Errorf("%s and %sDuration can't be used together", name, name)
+	case hasSeconds:
+		n, err := strconv.ParseInt(seconds, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%s %s is not a valid number", name, seconds)
+		}
+		return n, nil
+	case hasDuration:
+		d, err := time.ParseDuration(duration)
+		if err != nil {
+			return 0, fmt.Errorf("%sDuration %s is not a valid duration: %s", name, duration, err)
+		}
+		if d%time.Second != 0 {
+			return 0, fmt.Errorf("%sDuration %s must be a whole number of seconds", name, duration)
+		}
+		return int64(d / time.Second), nil
+	default:
+		return defaultValue, nil
+	}
+}
+
+// getCloudwatchSearchExpression wraps a search like {AWS/SQS,QueueName} ApproximateNumberOfMessagesVisible
+// in a SEARCH expression, the matching metrics are combined with searchAggregation (SUM, AVG, MAX or MIN, default SUM)
+func getCloudwatchSearchExpression(search string, aggregation string, metricStat string, metricStatPeriod int64) (string, error) {
+	if strings.TrimSpace(search) == "" {
+		return "", fmt.Errorf("search must not be empty")
+	}
+	if strings.Contains(search, "'") {
+		return "", fmt.Errorf("search %s must not contain single quotes", search)
+	}
+
+	switch aggregation {
+	case "":
+		aggregation = "SUM"
+	case "SUM", "AVG", "MAX", "MIN":
+	default:
+		return "", fmt.Errorf("searchAggregation %s is not valid, valid values are SUM, AVG, MAX and MIN", aggregation)
+	}
+
+	return fmt.Sprintf("%s(SEARCH('%s', '%s', %d))", aggregation, search, metricStat, metricStatPeriod), nil
+}
+
 func parseAwsCloudwatchMetadata(config *ScalerConfig) (*awsCloudwatchMetadata, error) {
 	meta, err := parseMetricValues(config)
-
 	if err != nil {
-		return nil, fmt.Errorf("an error occurred when the scaler tried to get the metrics values")
+		return nil, err
+	}
+
+	if val, ok := config.TriggerMetadata["expression"]; ok && val != "" {
+		meta.expression = val
+	}
+
+	if val, ok := config.TriggerMetadata["search"]; ok {
+		if meta.expression != "" {
+			return nil, fmt.Errorf("expression and search can't be used together")
+		}
+		meta.expression, err = getCloudwatchSearchExpression(val, config.TriggerMetadata["searchAggregation"], meta.metricStat, meta.metricStatPeriod)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if val, ok := config.TriggerMetadata["queries"]; ok && val != "" {
+		if meta.expression != "" {
+			return nil, fmt.Errorf("expression and queries can't be used together")
+		}
+		meta.queries, err = parseCloudwatchQueries(val)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if val, ok := config.TriggerMetadata["alarmName"]; ok && val != "" {
+		if meta.expression != "" || len(meta.queries) > 0 {
+			return nil, fmt.Errorf("alarmName can't be used together with expression or queries")
+		}
+		meta.alarmName = val
+	}
+
+	if val, ok := config.TriggerMetadata["insightRuleName"]; ok && val != "" {
+		if meta.expression != "" || len(meta.queries) > 0 || meta.alarmName != "" {
+			return nil, fmt.Errorf("insightRuleName can't be used together with expression, queries or alarmName")
+		}
+		meta.insightRuleName = val
+
+		meta.insightRuleMetric = "UniqueContributors"
+		if val, ok := config.TriggerMetadata["insightRuleMetric"]; ok && val != "" {
+			if !isValidCloudwatchInsightRuleMetric(val) {
+				return nil, fmt.Errorf("insightRuleMetric %s is not valid, valid values are %s", val, strings.Join(cloudwatchInsightRuleMetrics, ", "))
+			}
+			meta.insightRuleMetric = val
+		}
+	} else if config.TriggerMetadata["insightRuleMetric"] != "" {
+		return nil, fmt.Errorf("insightRuleName not given")
+	}
+
+	// namespace, metricName and dimensions are only required for a single metric
+	customQuery := meta.expression != "" || len(meta.queries) > 0 || meta.alarmName != "" || meta.insightRuleName != ""
+
+	if val, ok := config.TriggerMetadata["anomalyDetectionBandId"]; ok && val != "" {
+		if !hasCloudwatchExpressionQuery(meta.queries, val) {
+			return nil, fmt.Errorf("anomalyDetectionBandId %s must be the id of an expression in queries", val)
+		}
+		if len(meta.queries) < 2 {
+			return nil, fmt.Errorf("queries must contain a metric besides the anomaly detection band")
+		}
+		meta.anomalyDetectionBandID = val
+	}
+
+	if val, ok := config.TriggerMetadata["metricAggregation"]; ok && val != "" {
+		switch val {
+		case "last", "sum":
+			meta.metricAggregation = val
+		default:
+			return nil, fmt.Errorf("metricAggregation %s is not valid, valid values are last and sum", val)
+		}
+	} else {
+		meta.metricAggregation = "last"
+	}
+
+	if val, ok := config.TriggerMetadata["metricResultAggregation"]; ok && val != "" {
+		switch val {
+		case "first", "max", "sum", "avg":
+			meta.metricResultAggregation = val
+		default:
+			return nil, fmt.Errorf("metricResultAggregation %s is not valid, valid values are first, max, sum and avg", val)
+		}
+	} else if len(meta.queries) > 0 {
+		meta.metricResultAggregation = "max"
+	} else {
+		meta.metricResultAggregation = "first"
 	}
 
 	if val, ok := config.TriggerMetadata["namespace"]; ok && val != "" {
 		meta.namespace = val
-	} else {
+	} else if !customQuery {
 		return nil, fmt.Errorf("namespace not given")
 	}
 
 	if val, ok := config.TriggerMetadata["metricName"]; ok && val != "" {
 		meta.metricsName = val
-	} else {
+	} else if !customQuery {
 		return nil, fmt.Errorf("metric name not given")
 	}
 
-	if val, ok := config.TriggerMetadata["dimensionName"]; ok && val != "" {
-		meta.dimensionName = strings.Split(val, ";")
+	if val, ok := config.TriggerMetadata["dimensions"]; ok && val != "" {
+		if config.TriggerMetadata["dimensionName"] != "" || config.TriggerMetadata["dimensionValue"] != "" {
+			return nil, fmt.Errorf("dimensions can't be used together with dimensionName and dimensionValue")
+		}
+		meta.dimensionName, meta.dimensionValue, err = parseCloudwatchDimensions(val)
+		if err != nil {
+			return nil, err
+		}
 	} else {
-		return nil, fmt.Errorf("dimension name not given")
-	}
+		if val, ok := config.TriggerMetadata["dimensionName"]; ok && val != "" {
+			meta.dimensionName = strings.Split(val, ";")
+		} else if !customQuery {
+			return nil, fmt.Errorf("dimension name not given")
+		}
 
-	if val, ok := config.TriggerMetadata["dimensionValue"]; ok && val != "" {
-		meta.dimensionValue = strings.Split(val, ";")
-	} else {
-		return nil, fmt.Errorf("dimension value not given")
+		if val, ok := config.TriggerMetadata["dimensionValue"]; ok && val != "" {
+			meta.dimensionValue = strings.Split(val, ";")
+		} else if !customQuery {
+			return nil, fmt.Errorf("dimension value not given")
+		}
 	}
 
 	if len(meta.dimensionName) != len(meta.dimensionValue) {
 		return nil, fmt.Errorf("dimensionName and dimensionValue are not matching in size")
 	}
 
+	if val, ok := config.TriggerMetadata["denominatorMetricName"]; ok && val != "" {
+		if customQuery {
+			return nil, fmt.Errorf("denominatorMetricName can't be used together with expression, queries, search, alarmName or insightRuleName")
+		}
+		meta.denominatorMetricName = val
+
+		meta.denominatorNamespace = meta.namespace
+		if val, ok := config.TriggerMetadata["denominatorNamespace"]; ok && val != "" {
+			meta.denominatorNamespace = val
+		}
+		if val, ok := config.TriggerMetadata["denominatorDimensionName"]; ok && val != "" {
+			meta.denominatorDimensionName = strings.Split(val, ";")
+		}
+		if val, ok := config.TriggerMetadata["denominatorDimensionValue"]; ok && val != "" {
+			meta.denominatorDimensionValue = strings.Split(val, ";")
+		}
+		if len(meta.denominatorDimensionName) != len(meta.denominatorDimensionValue) {
+			return nil, fmt.Errorf("denominatorDimensionName and denominatorDimensionValue are not matching in size")
+		}
+
+		switch val := config.TriggerMetadata["zeroDenominatorBehavior"]; val {
+		case "", "zero":
+			meta.zeroDenominatorBehavior = "zero"
+		case "numerator":
+			meta.zeroDenominatorBehavior = val
+		default:
+			return nil, fmt.Errorf("zeroDenominatorBehavior %s is not valid, valid values are zero and numerator", val)
+		}
+	}
+
+	if val, ok := config.TriggerMetadata["externalMetricName"]; ok && val != "" {
+		meta.externalMetricName = val
+	}
+
 	if val, ok := config.TriggerMetadata["targetMetricValue"]; ok && val != "" {
 		targetMetricValue, err := strconv.ParseFloat(val, 64)
 		if err != nil {
@@ -156,34 +566,184 @@ func parseAwsCloudwatchMetadata(config *ScalerConfig) (*awsCloudwatchMetadata, e
 		return nil, fmt.Errorf("min metric value not given")
 	}
 
-	if val, ok := config.TriggerMetadata["metricCollectionTime"]; ok && val != "" {
-		metricCollectionTime, err := strconv.Atoi(val)
+	if val, ok := config.TriggerMetadata["metricTargetType"]; ok && val != "" {
+		switch v2beta2.MetricTargetType(val) {
+		case v2beta2.ValueMetricType, v2beta2.AverageValueMetricType:
+			meta.metricTargetType = v2beta2.MetricTargetType(val)
+		default:
+			return nil, fmt.Errorf("metricTargetType %s is not valid, valid values are Value and AverageValue", val)
+		}
+	} else {
+		meta.metricTargetType = v2beta2.AverageValueMetricType
+	}
+
+	meta.activationTargetValue = meta.minMetricValue
+	if val, ok := config.TriggerMetadata["activationTargetValue"]; ok && val != "" {
+		activationTargetValue, err := strconv.ParseFloat(val, 64)
 		if err != nil {
-			cloudwatchLog.Error(err, "Error parsing metricCollectionTime metadata")
-		} else {
-			meta.metricCollectionTime = int64(metricCollectionTime)
+			return nil, fmt.Errorf("activationTargetValue not a valid number")
 		}
+		meta.activationTargetValue = activationTargetValue
 	}
 
-	if val, ok := config.TriggerMetadata["metricStat"]; ok && val != "" {
-		meta.metricStat = val
+	for _, stat := range meta.metricStats {
+		if !isValidCloudwatchStat(stat) {
+			return nil, fmt.Errorf("metricStat %s is not valid, valid values are %s or a percentile like p99", stat, strings.Join(cloudwatchStatistics, ", "))
+		}
+	}
+
+	if len(meta.metricStats) > 1 {
+		if customQuery || meta.denominatorMetricName != "" {
+			return nil, fmt.Errorf("multiple metricStat can't be used together with expression, queries, search, alarmName, insightRuleName or denominatorMetricName")
+		}
+		switch val := config.TriggerMetadata["metricStatAggregation"]; val {
+		case "":
+			meta.metricStatAggregation = "max"
+		case "max", "min", "avg":
+			meta.metricStatAggregation = val
+		default:
+			return nil, fmt.Errorf("metricStatAggregation %s is not valid, valid values are max, min and avg", val)
+		}
+	}
+
+	if !isValidCloudwatchPeriod(meta.metricStatPeriod) {
+		return nil, fmt.Errorf("metricStatPeriod %d is not valid, it must be 1, 5, 10, 30 or a multiple of 60", meta.metricStatPeriod)
+	}
+
+	// long windows make CloudWatch return large result sets, maxMetricCollectionTime
+	// has to be raised explicitly to go beyond a day
+	maxMetricCollectionTime, err := parseCloudwatchSeconds(config, "maxMetricCollectionTime", defaultMaxMetricCollectionTime)
+	if err != nil {
+		return nil, err
+	}
+	if meta.metricCollectionTime > maxMetricCollectionTime {
+		return nil, fmt.Errorf("metricCollectionTime %d is larger than the maximum of %d seconds, raise maxMetricCollectionTime to use a longer window", meta.metricCollectionTime, maxMetricCollectionTime)
 	}
 
-	if val, ok := config.TriggerMetadata["metricStatPeriod"]; ok && val != "" {
-		metricStatPeriod, err := strconv.Atoi(val)
+	if meta.metricCollectionTime < meta.metricStatPeriod {
+		cloudwatchLog.Info("metricCollectionTime is smaller than metricStatPeriod, no datapoints will be received", "metricCollectionTime", meta.metricCollectionTime, "metricStatPeriod", meta.metricStatPeriod)
+	}
+
+	// CloudWatch only aggregates percentiles over periods that are a multiple of 60 seconds
+	for _, stat := range meta.metricStats {
+		if cloudwatchPercentileStatistic.MatchString(stat) && meta.metricStatPeriod%60 != 0 {
+			return nil, fmt.Errorf("metricStatPeriod must be a multiple of 60 for the percentile metricStat %s", stat)
+		}
+	}
+
+	if val, ok := config.TriggerMetadata["metricUnit"]; ok && val != "" {
+		if !isValidCloudwatchUnit(val) {
+			return nil, fmt.Errorf("metricUnit %s is not a valid CloudWatch unit", val)
+		}
+		meta.metricUnit = val
+	}
+
+	if val, ok := config.TriggerMetadata["metricEndTimeOffset"]; ok && val != "" {
+		metricEndTimeOffset, err := strconv.ParseInt(val, 10, 64)
 		if err != nil {
-			cloudwatchLog.Error(err, "Error parsing metricStatPeriod metadata")
-		} else {
-			meta.metricStatPeriod = int64(metricStatPeriod)
+			return nil, fmt.Errorf("metricEndTimeOffset not a valid number")
+		}
+		if metricEndTimeOffset < 0 {
+			return nil, fmt.Errorf("metricEndTimeOffset must not be negative")
+		}
+		meta.metricEndTimeOffset = metricEndTimeOffset
+	}
+
+	if val, ok := config.TriggerMetadata["minSampleCount"]; ok && val != "" {
+		minSampleCount, err := strconv.ParseInt(val, 10, 64)
+		if err != nil || minSampleCount < 0 {
+			return nil, fmt.Errorf("minSampleCount %s is not a valid number of datapoints", val)
+		}
+		if minSampleCount > 0 && customQuery {
+			return nil, fmt.Errorf("minSampleCount can't be used together with expression, queries, search, alarmName or insightRuleName")
+		}
+		meta.minSampleCount = minSampleCount
+	}
+
+	if val, ok := config.TriggerMetadata["softErrorCodes"]; ok && val != "" {
+		for _, code := range strings.Split(val, ",") {
+			meta.softErrorCodes = append(meta.softErrorCodes, strings.TrimSpace(code))
+		}
+	}
+
+	meta.ignoreNullValues = defaultIgnoreNullValues
+	if val, ok := config.TriggerMetadata["ignoreNullValues"]; ok && val != "" {
+		ignoreNullValues, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, fmt.Errorf("ignoreNullValues not a valid boolean")
 		}
+		meta.ignoreNullValues = ignoreNullValues
 	}
 
 	if val, ok := config.TriggerMetadata["awsRegion"]; ok && val != "" {
-		meta.awsRegion = val
+		for _, region := range strings.Split(val, ",") {
+			region = strings.TrimSpace(region)
+			if !awsRegionFormat.MatchString(region) {
+				return nil, fmt.Errorf("awsRegion %s is not a valid AWS region, expected a region like eu-west-1", region)
+			}
+			meta.awsRegions = append(meta.awsRegions, region)
+		}
+		meta.awsRegion = meta.awsRegions[0]
 	} else {
 		return nil, fmt.Errorf("no awsRegion given")
 	}
 
+	if val, ok := config.TriggerMetadata["regionAggregation"]; ok && val != "" {
+		switch val {
+		case "sum", "avg", "max":
+			meta.regionAggregation = val
+		default:
+			return nil, fmt.Errorf("regionAggregation %s is not valid, valid values are sum, avg and max", val)
+		}
+	} else {
+		meta.regionAggregation = "sum"
+	}
+
+	if meta.anomalyDetectionBandID != "" && len(meta.awsRegions) > 1 {
+		return nil, fmt.Errorf("anomalyDetectionBandId can't be used with multiple regions")
+	}
+
+	if meta.alarmName != "" && len(meta.awsRegions) > 1 {
+		return nil, fmt.Errorf("alarmName can't be used with multiple regions")
+	}
+
+	if val, ok := config.TriggerMetadata["awsAccountId"]; ok && val != "" {
+		if !awsAccountIDFormat.MatchString(val) {
+			return nil, fmt.Errorf("awsAccountId %s is not valid, it must be 12 digits", val)
+		}
+		if customQuery {
+			return nil, fmt.Errorf("awsAccountId can't be used together with expression, queries, search, alarmName or insightRuleName")
+		}
+		meta.awsAccountID = val
+	}
+
+	if val, ok := config.TriggerMetadata["awsEndpoint"]; ok && val != "" {
+		endpoint, err := url.Parse(val)
+		if err != nil || endpoint.Scheme == "" || endpoint.Host == "" {
+			return nil, fmt.Errorf("awsEndpoint %s is not a valid URL", val)
+		}
+		meta.awsEndpoint = val
+	}
+
+	if val, ok := config.TriggerMetadata["queryCacheTTL"]; ok && val != "" {
+		queryCacheTTL, err := time.ParseDuration(val)
+		if err != nil || queryCacheTTL < 0 {
+			return nil, fmt.Errorf("queryCacheTTL %s is not a valid duration", val)
+		}
+		meta.queryCacheTTL = queryCacheTTL
+	}
+
+	if val, ok := config.TriggerMetadata["useFIPS"]; ok && val != "" {
+		useFIPS, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, fmt.Errorf("useFIPS not a valid boolean")
+		}
+		if useFIPS && meta.awsEndpoint != "" {
+			return nil, fmt.Errorf("useFIPS can't be used together with awsEndpoint")
+		}
+		meta.useFIPS = useFIPS
+	}
+
 	auth, err := getAwsAuthorization(config.AuthParams, config.TriggerMetadata, config.ResolvedEnv)
 	if err != nil {
 		return nil, err
@@ -196,117 +756,788 @@ func parseAwsCloudwatchMetadata(config *ScalerConfig) (*awsCloudwatchMetadata, e
 	return meta, nil
 }
 
+// cloudwatchDimension is an entry of the dimensions metadata, unlike dimensionName
+// and dimensionValue the name and value may contain semicolons
+type cloudwatchDimension struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+func parseCloudwatchDimensions(val string) ([]string, []string, error) {
+	var dimensions []cloudwatchDimension
+	if err := json.Unmarshal([]byte(val), &dimensions); err != nil {
+		return nil, nil, fmt.Errorf("error parsing dimensions: %s", err)
+	}
+	if len(dimensions) == 0 {
+		return nil, nil, fmt.Errorf("dimensions must contain at least one dimension")
+	}
+
+	names := make([]string, 0, len(dimensions))
+	values := make([]string, 0, len(dimensions))
+	for _, dimension := range dimensions {
+		if dimension.Name == "" || dimension.Value == "" {
+			return nil, nil, fmt.Errorf("dimensions must have a name and a value")
+		}
+		names = append(names, dimension.Name)
+		values = append(values, dimension.Value)
+	}
+	return names, values, nil
+}
+
+func hasCloudwatchExpressionQuery(queries []*cloudwatch.MetricDataQuery, id string) bool {
+	for _, query := range queries {
+		if *query.Id == id && query.Expression != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func parseCloudwatchQueries(val string) ([]*cloudwatch.MetricDataQuery, error) {
+	var queries []*cloudwatch.MetricDataQuery
+	if err := json.Unmarshal([]byte(val), &queries); err != nil {
+		return nil, fmt.Errorf("error parsing queries: %s", err)
+	}
+	if len(queries) == 0 {
+		return nil, fmt.Errorf("queries must contain at least one query")
+	}
+
+	ids := map[string]bool{}
+	for _, query := range queries {
+		if query == nil {
+			return nil, fmt.Errorf("queries must not contain empty queries")
+		}
+		if err := query.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid query: %s", err)
+		}
+		if ids[*query.Id] {
+			return nil, fmt.Errorf("query id %s is not unique", *query.Id)
+		}
+		ids[*query.Id] = true
+	}
+	return queries, nil
+}
+
 func (c *awsCloudwatchScaler) GetMetrics(ctx context.Context, metricName string, metricSelector labels.Selector) ([]external_metrics.ExternalMetricValue, error) {
-	metricValue, err := c.GetCloudwatchMetrics()
+	value, ok := c.takeActiveValue()
+	if !ok {
+		var err error
+		value, err = c.getCloudwatchValue(ctx)
+		if err != nil {
+			cloudwatchLog.Error(err, "Error getting metric value")
+			return []external_metrics.ExternalMetricValue{}, err
+		}
+	}
 
-	if err != nil {
-		cloudwatchLog.Error(err, "Error getting metric value")
-		return []external_metrics.ExternalMetricValue{}, err
+	if len(value.messages) > 0 {
+		cloudwatchLog.Info("Received messages with the metric data", "messages", value.messages, "metricName", metricName)
+	}
+
+	// report when the datapoint was recorded, lagging metrics would look fresh with the current time
+	timestamp := metav1.Now()
+	if !value.timestamp.IsZero() {
+		timestamp = metav1.NewTime(value.timestamp)
 	}
 
 	metric := external_metrics.ExternalMetricValue{
 		MetricName: metricName,
-		Value:      *resource.NewQuantity(int64(metricValue), resource.DecimalSI),
-		Timestamp:  metav1.Now(),
+		Value:      *getCloudwatchQuantity(value.value),
+		Timestamp:  timestamp,
 	}
 
 	return append([]external_metrics.ExternalMetricValue{}, metric), nil
 }
 
 func (c *awsCloudwatchScaler) GetMetricSpecForScaling(context.Context) []v2beta2.MetricSpec {
-	targetMetricValue := resource.NewQuantity(int64(c.metadata.targetMetricValue), resource.DecimalSI)
+	targetMetricValue := getCloudwatchQuantity(c.metadata.targetMetricValue)
 	externalMetric := &v2beta2.ExternalMetricSource{
 		Metric: v2beta2.MetricIdentifier{
-			Name: GenerateMetricNameWithIndex(c.metadata.scalerIndex, kedautil.NormalizeString(fmt.Sprintf("%s-%s-%s-%s", "aws-cloudwatch", c.metadata.namespace, c.metadata.dimensionName[0], c.metadata.dimensionValue[0]))),
+			Name: GenerateMetricNameWithIndex(c.metadata.scalerIndex, c.getMetricName()),
 		},
 		Target: v2beta2.MetricTarget{
-			Type:         v2beta2.AverageValueMetricType,
-			AverageValue: targetMetricValue,
+			Type: c.metadata.metricTargetType,
 		},
 	}
+	if c.metadata.metricTargetType == v2beta2.ValueMetricType {
+		externalMetric.Target.Value = targetMetricValue
+	} else {
+		externalMetric.Target.AverageValue = targetMetricValue
+	}
 	metricSpec := v2beta2.MetricSpec{External: externalMetric, Type: externalMetricType}
 	return []v2beta2.MetricSpec{metricSpec}
 }
 
+// getCloudwatchQuantity keeps whole values as plain quantities, which consumers can read with AsInt64,
+// and only uses the milli scale for fractions like 0.5 requests per second that NewQuantity truncates
+func getCloudwatchQuantity(value float64) *resource.Quantity {
+	if value == math.Trunc(value) {
+		return resource.NewQuantity(int64(value), resource.DecimalSI)
+	}
+	return resource.NewMilliQuantity(int64(math.Round(value*1000)), resource.DecimalSI)
+}
+
+func (c *awsCloudwatchScaler) getMetricName() string {
+	if c.metadata.externalMetricName != "" {
+		return kedautil.NormalizeString(c.metadata.externalMetricName)
+	}
+
+	if c.metadata.alarmName != "" {
+		return kedautil.NormalizeString(fmt.Sprintf("%s-%s", "aws-cloudwatch-alarm", c.metadata.alarmName))
+	}
+
+	if c.metadata.insightRuleName != "" {
+		return kedautil.NormalizeString(fmt.Sprintf("%s-%s", "aws-cloudwatch-insight-rule", c.metadata.insightRuleName))
+	}
+
+	var prefix string
+	switch {
+	case c.metadata.expression != "":
+		prefix = "aws-cloudwatch-expression"
+	case len(c.metadata.queries) > 0:
+		prefix = "aws-cloudwatch-queries"
+	}
+	if prefix != "" {
+		if c.metadata.metricsName != "" {
+			return kedautil.NormalizeString(fmt.Sprintf("%s-%s", prefix, c.metadata.metricsName))
+		}
+		return prefix
+	}
+
+	// the dimensions are sent to AWS as given, but the name must not depend on their order
+	first := 0
+	for i := range c.metadata.dimensionName {
+		if c.metadata.dimensionName[i] < c.metadata.dimensionName[first] {
+			first = i
+		}
+	}
+	return kedautil.NormalizeString(fmt.Sprintf("%s-%s-%s-%s", "aws-cloudwatch", c.metadata.namespace, c.metadata.dimensionName[first], c.metadata.dimensionValue[first]))
+}
+
 func (c *awsCloudwatchScaler) IsActive(ctx context.Context) (bool, error) {
-	val, err := c.GetCloudwatchMetrics()
+	if c.metadata.anomalyDetectionBandID != "" {
+		return c.isAboveAnomalyDetectionBand(ctx)
+	}
+
+	value, err := c.getCloudwatchValue(ctx)
 
 	if err != nil {
 		return false, err
 	}
 
-	return val > c.metadata.minMetricValue, nil
+	c.setActiveValue(&value)
+	if c.metadata.alarmName != "" {
+		return value.value > 0, nil
+	}
+	return value.value > c.metadata.activationTargetValue, nil
+}
+
+func (c *awsCloudwatchScaler) setActiveValue(value *cloudwatchValue) {
+	c.activeValueLock.Lock()
+	defer c.activeValueLock.Unlock()
+	c.activeValue = value
+}
+
+// takeActiveValue returns the value fetched by IsActive and clears it, so it is only used once
+func (c *awsCloudwatchScaler) takeActiveValue() (cloudwatchValue, bool) {
+	c.activeValueLock.Lock()
+	defer c.activeValueLock.Unlock()
+	if c.activeValue == nil {
+		return cloudwatchValue{}, false
+	}
+	value := *c.activeValue
+	c.activeValue = nil
+	return value, true
 }
 
 func (c *awsCloudwatchScaler) Close(context.Context) error {
+	c.cwClients = nil
+	c.setActiveValue(nil)
+	if c.httpClient != nil {
+		c.httpClient.CloseIdleConnections()
+		c.httpClient = nil
+	}
 	return nil
 }
 
-func (c *awsCloudwatchScaler) GetCloudwatchMetrics() (float64, error) {
-	sess := session.Must(session.NewSession(&aws.Config{
-		Region: aws.String(c.metadata.awsRegion),
-	}))
+// GetCloudwatchMetrics returns the metric value and the timestamp of its datapoint,
+// the timestamp is zero when minMetricValue is used because no datapoints were received
+func (c *awsCloudwatchScaler) GetCloudwatchMetrics(ctx context.Context) (float64, time.Time, error) {
+	value, err := c.getCloudwatchValue(ctx)
+	return value.value, value.timestamp, err
+}
+
+// GetCloudwatchMetricsForWindow runs the scaler query over an arbitrary window, e.g. for diagnostics
+func (c *awsCloudwatchScaler) GetCloudwatchMetricsForWindow(ctx context.Context, startTime, endTime time.Time) (float64, time.Time, error) {
+	value, err := c.getCloudwatchValueForWindow(ctx, startTime, endTime)
+	return value.value, value.timestamp, err
+}
+
+func (c *awsCloudwatchScaler) getCloudwatchValue(ctx context.Context) (cloudwatchValue, error) {
+	startTime, endTime := c.getQueryWindow(c.now())
+	return c.getCloudwatchValueForWindow(ctx, startTime, endTime)
+}
+
+func (c *awsCloudwatchScaler) getCloudwatchValueForWindow(ctx context.Context, startTime, endTime time.Time) (cloudwatchValue, error) {
+	if len(c.cwClients) == 0 {
+		return cloudwatchValue{value: -1}, fmt.Errorf("cloudwatch client is closed")
+	}
+
+	// the alarm state is not read from a window of datapoints
+	if c.metadata.alarmName != "" {
+		value, timestamp, err := c.getAlarmValue(ctx)
+		return cloudwatchValue{value: value, timestamp: timestamp}, err
+	}
 
-	var cloudwatchClient *cloudwatch.CloudWatch
-	if c.metadata.awsAuthorization.podIdentityOwner {
-		creds := credentials.NewStaticCredentials(c.metadata.awsAuthorization.awsAccessKeyID, c.metadata.awsAuthorization.awsSecretAccessKey, "")
+	cloudwatchLog.V(2).Info("Querying cloudwatch metric", "namespace", c.metadata.namespace, "metricName", c.metadata.metricsName,
+		"dimensionName", c.metadata.dimensionName, "dimensionValue", c.metadata.dimensionValue, "expression", c.metadata.expression,
+		"metricStat", c.metadata.metricStat, "metricStatPeriod", c.metadata.metricStatPeriod, "startTime", startTime, "endTime", endTime)
 
-		if c.metadata.awsAuthorization.awsRoleArn != "" {
-			creds = stscreds.NewCredentials(sess, c.metadata.awsAuthorization.awsRoleArn)
+	var values []float64
+	var metricTimestamp time.Time
+	var messages []string
+	softErrors := 0
+	for i, cwClient := range c.cwClients {
+		var value float64
+		var timestamp time.Time
+		var ok bool
+		var err error
+		if c.metadata.insightRuleName != "" {
+			value, timestamp, ok, err = c.getInsightRuleValue(ctx, cwClient, startTime, endTime)
+		} else {
+			var output *cloudwatch.GetMetricDataOutput
+			output, err = c.getMetricData(ctx, cwClient, c.metadata.awsRegions[i], startTime, endTime)
+			if err == nil {
+				messages = append(messages, getMetricDataMessages(output)...)
+				value, timestamp, ok = c.getMetricValue(output)
+			}
+		}
+		if err != nil {
+			if c.isSoftError(err) {
+				cloudwatchLog.V(1).Info("Ignoring soft error, skipping the region", "error", err, "awsRegion", c.metadata.awsRegions[i])
+				softErrors++
+				continue
+			}
+			return cloudwatchValue{value: -1}, err
 		}
+		if ok {
+			values = append(values, value)
+			if timestamp.After(metricTimestamp) {
+				metricTimestamp = timestamp
+			}
+		}
+	}
 
-		cloudwatchClient = cloudwatch.New(sess, &aws.Config{
-			Region:      aws.String(c.metadata.awsRegion),
-			Credentials: creds,
+	// minMetricValue is only used for soft errors once no region could be queried
+	if softErrors == len(c.cwClients) {
+		cloudwatchLog.V(1).Info("Soft errors in every region, using minMetricValue", "minMetricValue", c.metadata.minMetricValue)
+		return cloudwatchValue{value: c.metadata.minMetricValue, unit: c.metadata.metricUnit}, nil
+	}
+
+	if len(values) == 0 {
+		if !c.metadata.ignoreNullValues {
+			return cloudwatchValue{value: -1, messages: messages}, fmt.Errorf("metric data not received")
+		}
+		cloudwatchLog.V(1).Info("No datapoints received, using minMetricValue", "minMetricValue", c.metadata.minMetricValue)
+		return cloudwatchValue{value: c.metadata.minMetricValue, unit: c.metadata.metricUnit, messages: messages}, nil
+	}
+
+	return cloudwatchValue{
+		value:     aggregateCloudwatchValues(values, c.metadata.regionAggregation),
+		timestamp: metricTimestamp,
+		unit:      c.metadata.metricUnit,
+		messages:  messages,
+	}, nil
+}
+
+// getMetricDataMessages returns the messages of the output and of its results as code: value
+func getMetricDataMessages(output *cloudwatch.GetMetricDataOutput) []string {
+	var messages []string
+	for _, message := range output.Messages {
+		messages = append(messages, fmt.Sprintf("%s: %s", aws.StringValue(message.Code), aws.StringValue(message.Value)))
+	}
+	for _, result := range output.MetricDataResults {
+		for _, message := range result.Messages {
+			messages = append(messages, fmt.Sprintf("%s %s: %s", aws.StringValue(result.Id), aws.StringValue(message.Code), aws.StringValue(message.Value)))
+		}
+	}
+	return messages
+}
+
+// getAlarmValue returns 1 when the alarm is in ALARM state and 0 otherwise,
+// the timestamp is the last time the state changed
+func (c *awsCloudwatchScaler) getAlarmValue(ctx context.Context) (float64, time.Time, error) {
+	input := cloudwatch.DescribeAlarmsInput{
+		AlarmNames: aws.StringSlice([]string{c.metadata.alarmName}),
+		AlarmTypes: aws.StringSlice([]string{cloudwatch.AlarmTypeCompositeAlarm, cloudwatch.AlarmTypeMetricAlarm}),
+	}
+
+	output, err := c.cwClients[0].DescribeAlarmsWithContext(ctx, &input)
+	if err != nil {
+		if c.isSoftError(err) {
+			cloudwatchLog.V(1).Info("Ignoring soft error, using minMetricValue", "error", err, "minMetricValue", c.metadata.minMetricValue)
+			return c.metadata.minMetricValue, time.Time{}, nil
+		}
+		cloudwatchLog.Error(err, "Failed to describe alarm", "alarmName", c.metadata.alarmName)
+		return -1, time.Time{}, err
+	}
+
+	if len(output.CompositeAlarms) > 0 {
+		alarm := output.CompositeAlarms[0]
+		return getAlarmStateValue(alarm.StateValue), aws.TimeValue(alarm.StateUpdatedTimestamp), nil
+	}
+	if len(output.MetricAlarms) > 0 {
+		alarm := output.MetricAlarms[0]
+		return getAlarmStateValue(alarm.StateValue), aws.TimeValue(alarm.StateUpdatedTimestamp), nil
+	}
+	return -1, time.Time{}, fmt.Errorf("alarm %s not found", c.metadata.alarmName)
+}
+
+// getInsightRuleValue returns the newest insightRuleMetric datapoint of the Contributor Insights rule report
+func (c *awsCloudwatchScaler) getInsightRuleValue(ctx context.Context, cwClient cloudwatchClient, startTime, endTime time.Time) (float64, time.Time, bool, error) {
+	input := cloudwatch.GetInsightRuleReportInput{
+		RuleName:  aws.String(c.metadata.insightRuleName),
+		StartTime: aws.Time(startTime),
+		EndTime:   aws.Time(endTime),
+		Period:    aws.Int64(c.metadata.metricStatPeriod),
+		Metrics:   aws.StringSlice([]string{c.metadata.insightRuleMetric}),
+		// only the aggregated datapoints are used, not the contributors themselves
+		MaxContributorCount: aws.Int64(1),
+	}
+
+	output, err := cwClient.GetInsightRuleReportWithContext(ctx, &input)
+	if err != nil {
+		cloudwatchLog.Error(err, "Failed to get insight rule report", "insightRuleName", c.metadata.insightRuleName)
+		return -1, time.Time{}, false, err
+	}
+
+	var value float64
+	var timestamp time.Time
+	found := false
+	for _, datapoint := range output.MetricDatapoints {
+		datapointValue := getInsightRuleDatapointValue(datapoint, c.metadata.insightRuleMetric)
+		if datapointValue == nil || datapoint.Timestamp == nil {
+			continue
+		}
+		if !found || datapoint.Timestamp.After(timestamp) {
+			value = *datapointValue
+			timestamp = *datapoint.Timestamp
+			found = true
+		}
+	}
+	return value, timestamp, found, nil
+}
+
+func getInsightRuleDatapointValue(datapoint *cloudwatch.InsightRuleMetricDatapoint, metric string) *float64 {
+	switch metric {
+	case "UniqueContributors":
+		return datapoint.UniqueContributors
+	case "MaxContributorValue":
+		return datapoint.MaxContributorValue
+	case "SampleCount":
+		return datapoint.SampleCount
+	case "Sum":
+		return datapoint.Sum
+	case "Minimum":
+		return datapoint.Minimum
+	case "Maximum":
+		return datapoint.Maximum
+	case "Average":
+		return datapoint.Average
+	}
+	return nil
+}
+
+func getAlarmStateValue(state *string) float64 {
+	if aws.StringValue(state) == cloudwatch.StateValueAlarm {
+		return 1
+	}
+	return 0
+}
+
+func (c *awsCloudwatchScaler) isSoftError(err error) bool {
+	var aerr awserr.Error
+	if !errors.As(err, &aerr) {
+		return false
+	}
+	for _, code := range c.metadata.softErrorCodes {
+		if aerr.Code() == code {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *awsCloudwatchScaler) getMetricData(ctx context.Context, cwClient cloudwatchClient, region string, startTime, endTime time.Time) (*cloudwatch.GetMetricDataOutput, error) {
+	input := cloudwatch.GetMetricDataInput{
+		StartTime:         aws.Time(startTime),
+		EndTime:           aws.Time(endTime),
+		MetricDataQueries: c.getMetricDataQueries(),
+		ScanBy:            aws.String(cloudwatch.ScanByTimestampDescending),
+	}
+
+	var output *cloudwatch.GetMetricDataOutput
+	var err error
+	if c.metadata.queryCacheTTL > 0 {
+		key := getCloudwatchQueryCacheKey(region, c.metadata, &input, c.metadata.queryCacheTTL)
+		output, err = sharedCloudwatchQueryCache.get(ctx, key, c.metadata.queryCacheTTL, func(ctx context.Context) (*cloudwatch.GetMetricDataOutput, error) {
+			return cwClient.GetMetricDataWithContext(ctx, &input)
 		})
 	} else {
-		cloudwatchClient = cloudwatch.New(sess, &aws.Config{
-			Region: aws.String(c.metadata.awsRegion),
-		})
+		output, err = cwClient.GetMetricDataWithContext(ctx, &input)
+	}
+
+	if err != nil {
+		cloudwatchLog.Error(err, "Failed to get output")
+		return nil, err
+	}
+
+	cloudwatchLog.V(1).Info("Received Metric Data", "data", output)
+	return output, nil
+}
+
+// isAboveAnomalyDetectionBand queries the metric and its band once, the scaler is active
+// when the metric value breaches the upper band
+func (c *awsCloudwatchScaler) isAboveAnomalyDetectionBand(ctx context.Context) (bool, error) {
+	if len(c.cwClients) == 0 {
+		return false, fmt.Errorf("cloudwatch client is closed")
+	}
+
+	startTime, endTime := c.getQueryWindow(c.now())
+	output, err := c.getMetricData(ctx, c.cwClients[0], c.metadata.awsRegion, startTime, endTime)
+	if err != nil {
+		return false, err
+	}
+
+	metricValue, metricTimestamp, ok := c.getMetricValue(output)
+	if !ok {
+		if !c.metadata.ignoreNullValues {
+			return false, fmt.Errorf("metric data not received")
+		}
+		return false, nil
+	}
+	c.setActiveValue(&cloudwatchValue{value: metricValue, timestamp: metricTimestamp, unit: c.metadata.metricUnit, messages: getMetricDataMessages(output)})
+
+	upperBand, ok := c.getAnomalyDetectionUpperBand(output)
+	if !ok {
+		return false, fmt.Errorf("anomaly detection band not received")
+	}
+
+	return metricValue > upperBand, nil
+}
+
+// getAnomalyDetectionUpperBand returns the newest upper value, the band is
+// returned by CloudWatch as two results sharing the band id
+func (c *awsCloudwatchScaler) getAnomalyDetectionUpperBand(output *cloudwatch.GetMetricDataOutput) (float64, bool) {
+	var upperBand float64
+	found := false
+	for _, result := range output.MetricDataResults {
+		if result.Id == nil || *result.Id != c.metadata.anomalyDetectionBandID {
+			continue
+		}
+		if value, _, ok := getLatestMetricDataValue(result); ok && (!found || value > upperBand) {
+			upperBand = value
+			found = true
+		}
+	}
+	return upperBand, found
+}
+
+func (c *awsCloudwatchScaler) now() time.Time {
+	if c.clock == nil {
+		return time.Now()
+	}
+	return c.clock()
+}
+
+// getQueryWindow shifts the window back by metricEndTimeOffset to account for the CloudWatch ingestion delay
+func (c *awsCloudwatchScaler) getQueryWindow(now time.Time) (time.Time, time.Time) {
+	endTime := now.Add(time.Second * -1 * time.Duration(c.metadata.metricEndTimeOffset))
+	startTime := endTime.Add(time.Second * -1 * time.Duration(c.metadata.metricCollectionTime))
+	return startTime, endTime
+}
+
+// getMetricValue aggregates the newest value of each result according to metricResultAggregation,
+// the timestamp is the newest one of the aggregated results
+func (c *awsCloudwatchScaler) getMetricValue(output *cloudwatch.GetMetricDataOutput) (float64, time.Time, bool) {
+	if c.metadata.minSampleCount > 0 {
+		if sampleCount, ok := c.getSampleCount(output); ok && sampleCount < float64(c.metadata.minSampleCount) {
+			cloudwatchLog.V(1).Info("Not enough datapoints, using minMetricValue", "sampleCount", sampleCount, "minSampleCount", c.metadata.minSampleCount, "minMetricValue", c.metadata.minMetricValue)
+			return c.metadata.minMetricValue, time.Time{}, true
+		}
+	}
+
+	if c.metadata.denominatorMetricName != "" {
+		return c.getRatioValue(output)
 	}
 
+	// the anomaly detection band and the sample count are not part of the metric value
+	results := []*cloudwatch.MetricDataResult{}
+	for _, result := range output.MetricDataResults {
+		id := aws.StringValue(result.Id)
+		if c.metadata.anomalyDetectionBandID != "" && id == c.metadata.anomalyDetectionBandID {
+			continue
+		}
+		if c.metadata.minSampleCount > 0 && id == cloudwatchSampleCountQueryID {
+			continue
+		}
+		results = append(results, result)
+	}
+
+	// CloudWatch may return no results at all, e.g. during a partial outage
+	if len(results) == 0 {
+		return 0, time.Time{}, false
+	}
+
+	aggregation := c.metadata.metricResultAggregation
+	if len(c.metadata.metricStats) > 1 {
+		aggregation = c.metadata.metricStatAggregation
+	}
+
+	if aggregation == "first" {
+		return c.getResultValue(results[0])
+	}
+
+	var values []float64
+	var latestTimestamp time.Time
+	for _, result := range results {
+		if value, timestamp, ok := c.getResultValue(result); ok {
+			values = append(values, value)
+			if timestamp.After(latestTimestamp) {
+				latestTimestamp = timestamp
+			}
+		}
+	}
+	if len(values) == 0 {
+		return 0, time.Time{}, false
+	}
+	return aggregateCloudwatchValues(values, aggregation), latestTimestamp, true
+}
+
+// getSampleCount returns the number of datapoints of the metric, it is not found
+// when the metric has no datapoints at all
+func (c *awsCloudwatchScaler) getSampleCount(output *cloudwatch.GetMetricDataOutput) (float64, bool) {
+	for _, result := range output.MetricDataResults {
+		if aws.StringValue(result.Id) == cloudwatchSampleCountQueryID {
+			sampleCount, _, ok := c.getResultValue(result)
+			return sampleCount, ok
+		}
+	}
+	return 0, false
+}
+
+// getRatioValue divides the value of the first metric by the value of the denominator metric
+func (c *awsCloudwatchScaler) getRatioValue(output *cloudwatch.GetMetricDataOutput) (float64, time.Time, bool) {
+	var numeratorResult, denominatorResult *cloudwatch.MetricDataResult
+	for _, result := range output.MetricDataResults {
+		switch aws.StringValue(result.Id) {
+		case "c1":
+			numeratorResult = result
+		case "c2":
+			denominatorResult = result
+		}
+	}
+	if numeratorResult == nil || denominatorResult == nil {
+		return 0, time.Time{}, false
+	}
+
+	numerator, timestamp, ok := c.getResultValue(numeratorResult)
+	if !ok {
+		return 0, time.Time{}, false
+	}
+	denominator, denominatorTimestamp, ok := c.getResultValue(denominatorResult)
+	if !ok {
+		return 0, time.Time{}, false
+	}
+	if denominatorTimestamp.After(timestamp) {
+		timestamp = denominatorTimestamp
+	}
+
+	if denominator == 0 {
+		if c.metadata.zeroDenominatorBehavior == "numerator" {
+			return numerator, timestamp, true
+		}
+		return 0, timestamp, true
+	}
+	return numerator / denominator, timestamp, true
+}
+
+// aggregateCloudwatchValues combines values with max, min, sum or avg, values must not be empty
+func aggregateCloudwatchValues(values []float64, aggregation string) float64 {
+	aggregated := values[0]
+	for _, value := range values[1:] {
+		switch aggregation {
+		case "max":
+			if value > aggregated {
+				aggregated = value
+			}
+		case "min":
+			if value < aggregated {
+				aggregated = value
+			}
+		case "sum", "avg":
+			aggregated += value
+		}
+	}
+	if aggregation == "avg" {
+		aggregated /= float64(len(values))
+	}
+	return aggregated
+}
+
+// getResultValue returns the newest datapoint, or the sum of all the datapoints in
+// the window when metricAggregation is sum
+func (c *awsCloudwatchScaler) getResultValue(result *cloudwatch.MetricDataResult) (float64, time.Time, bool) {
+	latestValue, latestTimestamp, found := getLatestMetricDataValue(result)
+	if c.metadata.metricAggregation != "sum" {
+		return latestValue, latestTimestamp, found
+	}
+
+	var sum float64
+	for _, value := range result.Values {
+		if value != nil {
+			sum += *value
+		}
+	}
+	return sum, latestTimestamp, found
+}
+
+// getLatestMetricDataValue returns the value of the datapoint with the most recent timestamp,
+// the newest datapoint isn't always the first one when the newest bucket isn't filled yet
+func getLatestMetricDataValue(result *cloudwatch.MetricDataResult) (float64, time.Time, bool) {
+	var latestValue float64
+	var latestTimestamp time.Time
+	found := false
+	for i, value := range result.Values {
+		if value == nil {
+			continue
+		}
+		var timestamp time.Time
+		if i < len(result.Timestamps) && result.Timestamps[i] != nil {
+			timestamp = *result.Timestamps[i]
+		}
+		if !found || timestamp.After(latestTimestamp) {
+			latestValue = *value
+			latestTimestamp = timestamp
+			found = true
+		}
+	}
+	return latestValue, latestTimestamp, found
+}
+
+func (c *awsCloudwatchScaler) getMetricDataQueries() []*cloudwatch.MetricDataQuery {
+	if len(c.metadata.queries) > 0 {
+		return c.metadata.queries
+	}
+	queries := []*cloudwatch.MetricDataQuery{}
+	if c.metadata.denominatorMetricName != "" {
+		queries = append(queries, c.getMetricDataQuery(), c.getDenominatorMetricDataQuery())
+	} else {
+		for i, stat := range c.metadata.metricStats {
+			queries = append(queries, c.getMetricDataQueryForStat(fmt.Sprintf("c%d", i+1), stat))
+		}
+	}
+	if c.metadata.minSampleCount > 0 {
+		queries = append(queries, c.getMetricDataQueryForStat(cloudwatchSampleCountQueryID, "SampleCount"))
+	}
+	return queries
+}
+
+func (c *awsCloudwatchScaler) getMetricDataQuery() *cloudwatch.MetricDataQuery {
+	return c.getMetricDataQueryForStat("c1", c.metadata.metricStat)
+}
+
+func (c *awsCloudwatchScaler) getMetricDataQueryForStat(id string, stat string) *cloudwatch.MetricDataQuery {
+	if c.metadata.expression != "" {
+		return &cloudwatch.MetricDataQuery{
+			Id:         aws.String(id),
+			Expression: aws.String(c.metadata.expression),
+			Period:     aws.Int64(c.metadata.metricStatPeriod),
+			ReturnData: aws.Bool(true),
+		}
+	}
+
+	metricStat := c.getMetricStat(c.metadata.namespace, c.metadata.metricsName, c.metadata.dimensionName, c.metadata.dimensionValue, stat)
+	if c.metadata.metricUnit != "" {
+		metricStat.Unit = aws.String(c.metadata.metricUnit)
+	}
+
+	return c.withAccountID(&cloudwatch.MetricDataQuery{
+		Id:         aws.String(id),
+		MetricStat: metricStat,
+		ReturnData: aws.Bool(true),
+	})
+}
+
+func (c *awsCloudwatchScaler) getDenominatorMetricDataQuery() *cloudwatch.MetricDataQuery {
+	return c.withAccountID(&cloudwatch.MetricDataQuery{
+		Id:         aws.String("c2"),
+		MetricStat: c.getMetricStat(c.metadata.denominatorNamespace, c.metadata.denominatorMetricName, c.metadata.denominatorDimensionName, c.metadata.denominatorDimensionValue, c.metadata.metricStat),
+		ReturnData: aws.Bool(true),
+	})
+}
+
+// withAccountID reads the metric of the query from awsAccountID when it is given
+func (c *awsCloudwatchScaler) withAccountID(query *cloudwatch.MetricDataQuery) *cloudwatch.MetricDataQuery {
+	if c.metadata.awsAccountID != "" {
+		query.AccountId = aws.String(c.metadata.awsAccountID)
+	}
+	return query
+}
+
+func (c *awsCloudwatchScaler) getMetricStat(namespace string, metricName string, dimensionName []string, dimensionValue []string, stat string) *cloudwatch.MetricStat {
 	dimensions := []*cloudwatch.Dimension{}
-	for i := range c.metadata.dimensionName {
+	for i := range dimensionName {
 		dimensions = append(dimensions, &cloudwatch.Dimension{
-			Name:  &c.metadata.dimensionName[i],
-			Value: &c.metadata.dimensionValue[i],
+			Name:  aws.String(dimensionName[i]),
+			Value: aws.String(dimensionValue[i]),
 		})
 	}
 
-	input := cloudwatch.GetMetricDataInput{
-		StartTime: aws.Time(time.Now().Add(time.Second * -1 * time.Duration(c.metadata.metricCollectionTime))),
-		EndTime:   aws.Time(time.Now()),
-		MetricDataQueries: []*cloudwatch.MetricDataQuery{
-			{
-				Id: aws.String("c1"),
-				MetricStat: &cloudwatch.MetricStat{
-					Metric: &cloudwatch.Metric{
-						Namespace:  aws.String(c.metadata.namespace),
-						Dimensions: dimensions,
-						MetricName: aws.String(c.metadata.metricsName),
-					},
-					Period: aws.Int64(c.metadata.metricStatPeriod),
-					Stat:   aws.String(c.metadata.metricStat),
-				},
-				ReturnData: aws.Bool(true),
-			},
+	return &cloudwatch.MetricStat{
+		Metric: &cloudwatch.Metric{
+			Namespace:  aws.String(namespace),
+			Dimensions: dimensions,
+			MetricName: aws.String(metricName),
 		},
+		Period: aws.Int64(c.metadata.metricStatPeriod),
+		Stat:   aws.String(stat),
 	}
+}
 
-	output, err := cloudwatchClient.GetMetricData(&input)
+func isValidCloudwatchStat(stat string) bool {
+	for _, s := range cloudwatchStatistics {
+		if s == stat {
+			return true
+		}
+	}
+	return cloudwatchPercentileStatistic.MatchString(stat)
+}
 
-	if err != nil {
-		cloudwatchLog.Error(err, "Failed to get output")
-		return -1, err
+// isValidCloudwatchPeriod follows the CloudWatch rules, high resolution periods
+// of 1, 5, 10 and 30 seconds or any multiple of 60 seconds
+func isValidCloudwatchPeriod(period int64) bool {
+	switch period {
+	case 1, 5, 10, 30:
+		return true
 	}
+	return period > 0 && period%60 == 0
+}
 
-	cloudwatchLog.V(1).Info("Received Metric Data", "data", output)
-	var metricValue float64
-	if output.MetricDataResults[0].Values != nil {
-		metricValue = *output.MetricDataResults[0].Values[0]
-	} else {
-		return -1, fmt.Errorf("metric data not received")
+// isValidCloudwatchInsightRuleMetric checks the metric against the ones GetInsightRuleReport returns
+func isValidCloudwatchInsightRuleMetric(metric string) bool {
+	for _, m := range cloudwatchInsightRuleMetrics {
+		if metric == m {
+			return true
+		}
 	}
+	return false
+}
 
-	return metricValue, nil
+func isValidCloudwatchUnit(unit string) bool {
+	for _, u := range cloudwatch.StandardUnit_Values() {
+		if u == unit {
+			return true
+		}
+	}
+	return false
 }
@@ -2,7 +2,24 @@ package scalers
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/go-logr/logr"
+	"k8s.io/api/autoscaling/v2beta2"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 var testAWSCloudwatchRoleArn = "none"
@@ -20,6 +37,95 @@ var testAWSAuthentication = map[string]string{
 	"awsSecretAccessKey": testAWSCloudwatchSecretAccessKey,
 }
 
+const testAWSCloudwatchExpression = `SELECT MIN(MessageCount) FROM "AWS/AmazonMQ" WHERE Broker = 'production' and Queue = 'worker'`
+
+// newTestAWSCloudwatchMetadata returns the metadata of a trigger on the visible messages
+// of an SQS queue, with the overrides applied on top and an empty override removing the key
+func newTestAWSCloudwatchMetadata(overrides map[string]string) map[string]string {
+	return withTestAWSCloudwatchOverrides(map[string]string{
+		"namespace":         "AWS/SQS",
+		"dimensionName":     "QueueName",
+		"dimensionValue":    "keda",
+		"metricName":        "ApproximateNumberOfMessagesVisible",
+		"targetMetricValue": "2",
+		"minMetricValue":    "0",
+		"awsRegion":         "eu-west-1",
+	}, overrides)
+}
+
+// newTestAWSCloudwatchExpressionMetadata returns the metadata of a trigger on testAWSCloudwatchExpression
+func newTestAWSCloudwatchExpressionMetadata(overrides map[string]string) map[string]string {
+	return withTestAWSCloudwatchOverrides(map[string]string{
+		"expression":        testAWSCloudwatchExpression,
+		"targetMetricValue": "2",
+		"minMetricValue":    "0",
+		"awsRegion":         "eu-west-1",
+	}, overrides)
+}
+
+// newTestAWSCloudwatchQueriesMetadata returns the metadata of a trigger on the EC2 CPU and SQS queue queries
+func newTestAWSCloudwatchQueriesMetadata() map[string]string {
+	return map[string]string{
+		"queries":           `[{"id":"cpu","metricStat":{"metric":{"namespace":"AWS/EC2","metricName":"CPUUtilization","dimensions":[{"name":"AutoScalingGroupName","value":"keda"}]},"period":60,"stat":"Average"}},{"id":"queue","metricStat":{"metric":{"namespace":"AWS/SQS","metricName":"ApproximateNumberOfMessagesVisible","dimensions":[{"name":"QueueName","value":"keda"}]},"period":60,"stat":"Sum"}}]`,
+		"targetMetricValue": "2",
+		"minMetricValue":    "0",
+		"awsRegion":         "eu-west-1",
+	}
+}
+
+func withTestAWSCloudwatchOverrides(metadata map[string]string, overrides map[string]string) map[string]string {
+	for k, v := range overrides {
+		if v == "" {
+			delete(metadata, k)
+			continue
+		}
+		metadata[k] = v
+	}
+	return metadata
+}
+
+// parseTestAWSCloudwatchMetadata parses the metadata with testAWSAuthentication and fails the test on error
+func parseTestAWSCloudwatchMetadata(t *testing.T, metadata map[string]string) *awsCloudwatchMetadata {
+	t.Helper()
+	meta, err := parseAwsCloudwatchMetadata(&ScalerConfig{TriggerMetadata: metadata, ResolvedEnv: testAWSCloudwatchResolvedEnv, AuthParams: testAWSAuthentication})
+	if err != nil {
+		t.Fatal("Could not parse metadata:", err)
+	}
+	return meta
+}
+
+// mockCloudwatch is a fake cloudwatchClient returning a fixed output after the given latency
+type mockCloudwatch struct {
+	calls   int
+	input   *cloudwatch.GetMetricDataInput
+	output  *cloudwatch.GetMetricDataOutput
+	err     error
+	latency time.Duration
+	alarms  *cloudwatch.DescribeAlarmsOutput
+	report  *cloudwatch.GetInsightRuleReportOutput
+}
+
+func (m *mockCloudwatch) GetMetricDataWithContext(ctx aws.Context, input *cloudwatch.GetMetricDataInput, opts ...request.Option) (*cloudwatch.GetMetricDataOutput, error) {
+	m.calls++
+	m.input = input
+	select {
+	case <-time.After(m.latency):
+		return m.output, m.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (m *mockCloudwatch) DescribeAlarmsWithContext(ctx aws.Context, input *cloudwatch.DescribeAlarmsInput, opts ...request.Option) (*cloudwatch.DescribeAlarmsOutput, error) {
+	m.calls++
+	return m.alarms, m.err
+}
+
+func (m *mockCloudwatch) GetInsightRuleReportWithContext(ctx aws.Context, input *cloudwatch.GetInsightRuleReportInput, opts ...request.Option) (*cloudwatch.GetInsightRuleReportOutput, error) {
+	m.calls++
+	return m.report, m.err
+}
+
 type parseAWSCloudwatchMetadataTestData struct {
 	metadata   map[string]string
 	authParams map[string]string
@@ -232,11 +338,379 @@ var testAWSCloudwatchMetadata = []parseAWSCloudwatchMetadataTestData{
 		"awsRegion":            "eu-west-1"},
 		testAWSAuthentication, false,
 		"Missing metricStatPeriod not generate error because will get the default value"},
+	{map[string]string{
+		"expression":        "SELECT MIN(MessageCount) FROM \"AWS/AmazonMQ\" WHERE Broker = 'production' and Queue = 'worker'",
+		"targetMetricValue": "2",
+		"minMetricValue":    "0",
+		"awsRegion":         "eu-west-1"},
+		testAWSAuthentication, false,
+		"Expression without namespace, metricName and dimensions"},
+	{map[string]string{
+		"expression":        "SELECT MIN(MessageCount) FROM \"AWS/AmazonMQ\" WHERE Broker = 'production' and Queue = 'worker'",
+		"metricName":        "MessageCount",
+		"targetMetricValue": "2",
+		"minMetricValue":    "0",
+		"awsRegion":         "eu-west-1"},
+		testAWSAuthentication, false,
+		"Expression with metricName"},
+	{map[string]string{
+		"targetMetricValue": "2",
+		"minMetricValue":    "0",
+		"awsRegion":         "eu-west-1"},
+		testAWSAuthentication, true,
+		"Missing both expression and metricName"},
+	{map[string]string{
+		"namespace":         "AWS/SQS",
+		"dimensionName":     "QueueName",
+		"dimensionValue":    "keda",
+		"metricName":        "ApproximateNumberOfMessagesVisible",
+		"targetMetricValue": "2",
+		"minMetricValue":    "0",
+		"metricUnit":        "Count",
+		"awsRegion":         "eu-west-1"},
+		testAWSAuthentication, false,
+		"Valid metricUnit"},
+	{map[string]string{
+		"namespace":         "AWS/SQS",
+		"dimensionName":     "QueueName",
+		"dimensionValue":    "keda",
+		"metricName":        "ApproximateNumberOfMessagesVisible",
+		"targetMetricValue": "2",
+		"minMetricValue":    "0",
+		"metricUnit":        "Hour",
+		"awsRegion":         "eu-west-1"},
+		testAWSAuthentication, true,
+		"Invalid metricUnit"},
+	{map[string]string{
+		"namespace":         "AWS/SQS",
+		"dimensionName":     "QueueName",
+		"dimensionValue":    "keda",
+		"metricName":        "ApproximateNumberOfMessagesVisible",
+		"targetMetricValue": "2",
+		"minMetricValue":    "0",
+		"ignoreNullValues":  "false",
+		"awsRegion":         "eu-west-1"},
+		testAWSAuthentication, false,
+		"Valid ignoreNullValues"},
+	{map[string]string{
+		"namespace":         "AWS/SQS",
+		"dimensionName":     "QueueName",
+		"dimensionValue":    "keda",
+		"metricName":        "ApproximateNumberOfMessagesVisible",
+		"targetMetricValue": "2",
+		"minMetricValue":    "0",
+		"ignoreNullValues":  "maybe",
+		"awsRegion":         "eu-west-1"},
+		testAWSAuthentication, true,
+		"Invalid ignoreNullValues"},
+	{map[string]string{
+		"queries":           `[{"id":"cpu","metricStat":{"metric":{"namespace":"AWS/EC2","metricName":"CPUUtilization","dimensions":[{"name":"AutoScalingGroupName","value":"keda"}]},"period":60,"stat":"Average"}},{"id":"queue","metricStat":{"metric":{"namespace":"AWS/SQS","metricName":"ApproximateNumberOfMessagesVisible","dimensions":[{"name":"QueueName","value":"keda"}]},"period":60,"stat":"Sum"}}]`,
+		"targetMetricValue": "2",
+		"minMetricValue":    "0",
+		"awsRegion":         "eu-west-1"},
+		testAWSAuthentication, false,
+		"Multiple queries"},
+	{map[string]string{
+		"queries":           `[{"id":"m1","expression":"SEARCH('{AWS/EC2} CPUUtilization', 'Average', 60)"},{"id":"m1","expression":"SEARCH('{AWS/SQS} NumberOfMessagesSent', 'Sum', 60)"}]`,
+		"targetMetricValue": "2",
+		"minMetricValue":    "0",
+		"awsRegion":         "eu-west-1"},
+		testAWSAuthentication, true,
+		"Multiple queries with duplicated ids"},
+	{map[string]string{
+		"queries":           `[{"expression":"SEARCH('{AWS/EC2} CPUUtilization', 'Average', 60)"}]`,
+		"targetMetricValue": "2",
+		"minMetricValue":    "0",
+		"awsRegion":         "eu-west-1"},
+		testAWSAuthentication, true,
+		"Query without id"},
+	{map[string]string{
+		"queries":           `{"id":"m1"}`,
+		"targetMetricValue": "2",
+		"minMetricValue":    "0",
+		"awsRegion":         "eu-west-1"},
+		testAWSAuthentication, true,
+		"Queries not a JSON array"},
+	{map[string]string{
+		"namespace":           "AWS/SQS",
+		"dimensionName":       "QueueName",
+		"dimensionValue":      "keda",
+		"metricName":          "ApproximateNumberOfMessagesVisible",
+		"targetMetricValue":   "2",
+		"minMetricValue":      "0",
+		"metricEndTimeOffset": "60",
+		"awsRegion":           "eu-west-1"},
+		testAWSAuthentication, false,
+		"Valid metricEndTimeOffset"},
+	{map[string]string{
+		"namespace":           "AWS/SQS",
+		"dimensionName":       "QueueName",
+		"dimensionValue":      "keda",
+		"metricName":          "ApproximateNumberOfMessagesVisible",
+		"targetMetricValue":   "2",
+		"minMetricValue":      "0",
+		"metricEndTimeOffset": "-60",
+		"awsRegion":           "eu-west-1"},
+		testAWSAuthentication, true,
+		"Negative metricEndTimeOffset"},
+	{map[string]string{
+		"namespace":         "AWS/SQS",
+		"dimensionName":     "QueueName",
+		"dimensionValue":    "keda",
+		"metricName":        "ApproximateNumberOfMessagesVisible",
+		"targetMetricValue": "2",
+		"minMetricValue":    "0",
+		"awsEndpoint":       "http://localstack:4566",
+		"awsRegion":         "eu-west-1"},
+		testAWSAuthentication, false,
+		"Valid awsEndpoint"},
+	{map[string]string{
+		"namespace":         "AWS/SQS",
+		"dimensionName":     "QueueName",
+		"dimensionValue":    "keda",
+		"metricName":        "ApproximateNumberOfMessagesVisible",
+		"targetMetricValue": "2",
+		"minMetricValue":    "0",
+		"awsEndpoint":       "localstack",
+		"awsRegion":         "eu-west-1"},
+		testAWSAuthentication, true,
+		"awsEndpoint not a URL"},
+	{map[string]string{
+		"namespace":         "AWS/SQS",
+		"dimensionName":     "QueueName",
+		"dimensionValue":    "keda",
+		"metricName":        "ApproximateNumberOfMessagesVisible",
+		"targetMetricValue": "2",
+		"minMetricValue":    "0",
+		"awsAccountId":      "123456789012",
+		"awsRegion":         "eu-west-1"},
+		testAWSAuthentication, false,
+		"Valid awsAccountId"},
+	{map[string]string{
+		"namespace":         "AWS/SQS",
+		"dimensionName":     "QueueName",
+		"dimensionValue":    "keda",
+		"metricName":        "ApproximateNumberOfMessagesVisible",
+		"targetMetricValue": "2",
+		"minMetricValue":    "0",
+		"awsAccountId":      "12345",
+		"awsRegion":         "eu-west-1"},
+		testAWSAuthentication, true,
+		"awsAccountId not 12 digits"},
+	{map[string]string{
+		"expression":        testAWSCloudwatchExpression,
+		"targetMetricValue": "2",
+		"minMetricValue":    "0",
+		"awsAccountId":      "123456789012",
+		"awsRegion":         "eu-west-1"},
+		testAWSAuthentication, true,
+		"awsAccountId with an expression"},
+	{map[string]string{
+		"namespace":         "AWS/SQS",
+		"dimensionName":     "QueueName",
+		"dimensionValue":    "keda",
+		"metricName":        "ApproximateNumberOfMessagesVisible",
+		"targetMetricValue": "0.5",
+		"minMetricValue":    "0",
+		"awsRegion":         "eu-west-1"},
+		testAWSAuthentication, false,
+		"Fractional targetMetricValue"},
+	{map[string]string{
+		"namespace":             "AWS/SQS",
+		"dimensionName":         "QueueName",
+		"dimensionValue":        "keda",
+		"metricName":            "ApproximateNumberOfMessagesVisible",
+		"targetMetricValue":     "2",
+		"minMetricValue":        "0",
+		"activationTargetValue": "5",
+		"awsRegion":             "eu-west-1"},
+		testAWSAuthentication, false,
+		"Valid activationTargetValue"},
+	{map[string]string{
+		"namespace":             "AWS/SQS",
+		"dimensionName":         "QueueName",
+		"dimensionValue":        "keda",
+		"metricName":            "ApproximateNumberOfMessagesVisible",
+		"targetMetricValue":     "2",
+		"minMetricValue":        "0",
+		"activationTargetValue": "five",
+		"awsRegion":             "eu-west-1"},
+		testAWSAuthentication, true,
+		"Invalid activationTargetValue"},
+	{map[string]string{
+		"namespace":         "AWS/SQS",
+		"dimensionName":     "QueueName",
+		"dimensionValue":    "keda",
+		"metricName":        "ApproximateNumberOfMessagesVisible",
+		"targetMetricValue": "2",
+		"minMetricValue":    "0",
+		"metricStat":        "p99.9",
+		"awsRegion":         "eu-west-1"},
+		testAWSAuthentication, false,
+		"Valid percentile metricStat"},
+	{map[string]string{
+		"namespace":         "AWS/SQS",
+		"dimensionName":     "QueueName",
+		"dimensionValue":    "keda",
+		"metricName":        "ApproximateNumberOfMessagesVisible",
+		"targetMetricValue": "2",
+		"minMetricValue":    "0",
+		"metricStat":        "Avg",
+		"awsRegion":         "eu-west-1"},
+		testAWSAuthentication, true,
+		"Invalid metricStat"},
+	{map[string]string{
+		"namespace":         "AWS/SQS",
+		"dimensionName":     "QueueName",
+		"dimensionValue":    "keda",
+		"metricName":        "ApproximateNumberOfMessagesVisible",
+		"targetMetricValue": "2",
+		"minMetricValue":    "0",
+		"metricStat":        "p95",
+		"metricStatPeriod":  "120",
+		"awsRegion":         "eu-west-1"},
+		testAWSAuthentication, false,
+		"Percentile metricStat with a valid metricStatPeriod"},
+	{map[string]string{
+		"namespace":         "AWS/SQS",
+		"dimensionName":     "QueueName",
+		"dimensionValue":    "keda",
+		"metricName":        "ApproximateNumberOfMessagesVisible",
+		"targetMetricValue": "2",
+		"minMetricValue":    "0",
+		"metricStat":        "p95",
+		"metricStatPeriod":  "90",
+		"awsRegion":         "eu-west-1"},
+		testAWSAuthentication, true,
+		"Percentile metricStat with an invalid metricStatPeriod"},
+	{map[string]string{
+		"namespace":         "AWS/SQS",
+		"dimensionName":     "QueueName",
+		"dimensionValue":    "keda",
+		"metricName":        "ApproximateNumberOfMessagesVisible",
+		"targetMetricValue": "2",
+		"minMetricValue":    "0",
+		"metricStatPeriod":  "45",
+		"awsRegion":         "eu-west-1"},
+		testAWSAuthentication, true,
+		"metricStatPeriod not a valid CloudWatch period"},
+	{map[string]string{
+		"namespace":         "AWS/SQS",
+		"dimensionName":     "QueueName",
+		"dimensionValue":    "keda",
+		"metricName":        "ApproximateNumberOfMessagesVisible",
+		"targetMetricValue": "2",
+		"minMetricValue":    "0",
+		"metricStatPeriod":  "120",
+		"awsRegion":         "eu-west-1"},
+		testAWSAuthentication, false,
+		"metricStatPeriod a multiple of 60"},
+	{map[string]string{
+		"namespace":         "AWS/SNS",
+		"dimensions":        `[{"name": "TopicName", "value": "keda"}, {"name": "Tag", "value": "team=a;env=b"}]`,
+		"metricName":        "NumberOfMessagesPublished",
+		"targetMetricValue": "2",
+		"minMetricValue":    "0",
+		"awsRegion":         "eu-west-1"},
+		testAWSAuthentication, false,
+		"Valid dimensions"},
+	{map[string]string{
+		"namespace":         "AWS/SNS",
+		"dimensions":        `[{"name": "TopicName", "value": "keda"}]`,
+		"dimensionName":     "TopicName",
+		"dimensionValue":    "keda",
+		"metricName":        "NumberOfMessagesPublished",
+		"targetMetricValue": "2",
+		"minMetricValue":    "0",
+		"awsRegion":         "eu-west-1"},
+		testAWSAuthentication, true,
+		"dimensions together with dimensionName and dimensionValue"},
+	{map[string]string{
+		"namespace":         "AWS/SNS",
+		"dimensions":        `[{"name": "TopicName"}]`,
+		"metricName":        "NumberOfMessagesPublished",
+		"targetMetricValue": "2",
+		"minMetricValue":    "0",
+		"awsRegion":         "eu-west-1"},
+		testAWSAuthentication, true,
+		"dimensions without a value"},
+	{map[string]string{
+		"expression":              "SEARCH('{AWS/SQS,QueueName} MetricName=\"ApproximateNumberOfMessagesVisible\"', 'Sum', 300)",
+		"metricResultAggregation": "sum",
+		"targetMetricValue":       "2",
+		"minMetricValue":          "0",
+		"awsRegion":               "eu-west-1"},
+		testAWSAuthentication, false,
+		"Valid metricResultAggregation"},
+	{map[string]string{
+		"expression":              "SEARCH('{AWS/SQS,QueueName} MetricName=\"ApproximateNumberOfMessagesVisible\"', 'Sum', 300)",
+		"metricResultAggregation": "median",
+		"targetMetricValue":       "2",
+		"minMetricValue":          "0",
+		"awsRegion":               "eu-west-1"},
+		testAWSAuthentication, true,
+		"Invalid metricResultAggregation"},
+	{map[string]string{
+		"namespace":         "AWS/SQS",
+		"dimensionName":     "QueueName",
+		"dimensionValue":    "keda",
+		"metricName":        "NumberOfMessagesSent",
+		"targetMetricValue": "2",
+		"minMetricValue":    "0",
+		"metricStat":        "Sum",
+		"metricStatPeriod":  "60",
+		"metricAggregation": "sum",
+		"awsRegion":         "eu-west-1"},
+		testAWSAuthentication, false,
+		"Valid metricAggregation"},
+	{map[string]string{
+		"namespace":         "AWS/SQS",
+		"dimensionName":     "QueueName",
+		"dimensionValue":    "keda",
+		"metricName":        "NumberOfMessagesSent",
+		"targetMetricValue": "2",
+		"minMetricValue":    "0",
+		"metricAggregation": "first",
+		"awsRegion":         "eu-west-1"},
+		testAWSAuthentication, true,
+		"Invalid metricAggregation"},
+	{map[string]string{
+		"namespace":          "AWS/SQS",
+		"dimensionName":      "QueueName",
+		"dimensionValue":     "keda",
+		"metricName":         "ApproximateNumberOfMessagesVisible",
+		"externalMetricName": "orders/backlog",
+		"targetMetricValue":  "2",
+		"minMetricValue":     "0",
+		"awsRegion":          "eu-west-1"},
+		testAWSAuthentication, false,
+		"Valid externalMetricName"},
+	{map[string]string{
+		"queries":                `[{"Id": "m1", "MetricStat": {"Metric": {"Namespace": "AWS/ApplicationELB", "MetricName": "RequestCount"}, "Period": 300, "Stat": "Sum"}, "ReturnData": true}, {"Id": "ad1", "Expression": "ANOMALY_DETECTION_BAND(m1, 2)", "ReturnData": true}]`,
+		"anomalyDetectionBandId": "ad1",
+		"targetMetricValue":      "100",
+		"minMetricValue":         "0",
+		"awsRegion":              "eu-west-1"},
+		testAWSAuthentication, false,
+		"Valid anomalyDetectionBandId"},
+	{map[string]string{
+		"queries":                `[{"Id": "m1", "MetricStat": {"Metric": {"Namespace": "AWS/ApplicationELB", "MetricName": "RequestCount"}, "Period": 300, "Stat": "Sum"}, "ReturnData": true}]`,
+		"anomalyDetectionBandId": "m1",
+		"targetMetricValue":      "100",
+		"minMetricValue":         "0",
+		"awsRegion":              "eu-west-1"},
+		testAWSAuthentication, true,
+		"anomalyDetectionBandId not an expression"},
 }
 
 var awsCloudwatchMetricIdentifiers = []awsCloudwatchMetricIdentifier{
 	{&testAWSCloudwatchMetadata[1], 0, "s0-aws-cloudwatch-AWS-SQS-QueueName-keda"},
 	{&testAWSCloudwatchMetadata[1], 3, "s3-aws-cloudwatch-AWS-SQS-QueueName-keda"},
+	{&parseAWSCloudwatchMetadataTestData{metadata: newTestAWSCloudwatchExpressionMetadata(nil), authParams: testAWSAuthentication}, 0, "s0-aws-cloudwatch-expression"},
+	{&parseAWSCloudwatchMetadataTestData{metadata: newTestAWSCloudwatchExpressionMetadata(map[string]string{"metricName": "MessageCount"}), authParams: testAWSAuthentication}, 1, "s1-aws-cloudwatch-expression-MessageCount"},
+	{&parseAWSCloudwatchMetadataTestData{metadata: newTestAWSCloudwatchQueriesMetadata(), authParams: testAWSAuthentication}, 2, "s2-aws-cloudwatch-queries"},
+	{&parseAWSCloudwatchMetadataTestData{metadata: newTestAWSCloudwatchMetadata(map[string]string{"externalMetricName": "orders/backlog"}), authParams: testAWSAuthentication}, 0, "s0-orders-backlog"},
+	{&parseAWSCloudwatchMetadataTestData{metadata: newTestAWSCloudwatchMetadata(map[string]string{"externalMetricName": "orders/backlog"}), authParams: testAWSAuthentication}, 1, "s1-orders-backlog"},
 }
 
 func TestCloudwatchParseMetadata(t *testing.T) {
@@ -251,19 +725,1565 @@ func TestCloudwatchParseMetadata(t *testing.T) {
 	}
 }
 
-func TestAWSCloudwatchGetMetricSpecForScaling(t *testing.T) {
-	for _, testData := range awsCloudwatchMetricIdentifiers {
-		ctx := context.Background()
-		meta, err := parseAwsCloudwatchMetadata(&ScalerConfig{TriggerMetadata: testData.metadataTestData.metadata, ResolvedEnv: testAWSCloudwatchResolvedEnv, AuthParams: testData.metadataTestData.authParams, ScalerIndex: testData.scalerIndex})
+func TestCloudwatchParseInvalidMetricStatPeriod(t *testing.T) {
+	_, err := parseAwsCloudwatchMetadata(&ScalerConfig{TriggerMetadata: newTestAWSCloudwatchMetadata(map[string]string{"metricStatPeriod": "a"}), ResolvedEnv: testAWSCloudwatchResolvedEnv, AuthParams: testAWSAuthentication})
+	if err == nil {
+		t.Fatal("Expected an error for an invalid metricStatPeriod")
+	}
+	if !strings.Contains(err.Error(), "metricStatPeriod a is not a valid number") {
+		t.Errorf("Expected the metricStatPeriod parse error but got %s", err)
+	}
+}
+
+func TestCloudwatchParseDurations(t *testing.T) {
+	tests := []struct {
+		settings             map[string]string
+		metricCollectionTime int64
+		metricStatPeriod     int64
+		isError              bool
+	}{
+		{map[string]string{"metricCollectionTimeDuration": "10m", "metricStatPeriodDuration": "1m"}, 600, 60, false},
+		{map[string]string{"metricCollectionTime": "600", "metricStatPeriodDuration": "2m"}, 600, 120, false},
+		{map[string]string{"metricCollectionTimeDuration": "1h", "metricStatPeriod": "300"}, 3600, 300, false},
+		{map[string]string{"metricCollectionTime": "600", "metricCollectionTimeDuration": "10m"}, 0, 0, true},
+		{map[string]string{"metricStatPeriod": "60", "metricStatPeriodDuration": "1m"}, 0, 0, true},
+		{map[string]string{"metricStatPeriodDuration": "five minutes"}, 0, 0, true},
+		{map[string]string{"metricCollectionTimeDuration": "1500ms"}, 0, 0, true},
+	}
+	for _, test := range tests {
+		metadata := newTestAWSCloudwatchMetadata(nil)
+		for k, v := range test.settings {
+			metadata[k] = v
+		}
+		meta, err := parseAwsCloudwatchMetadata(&ScalerConfig{TriggerMetadata: metadata, ResolvedEnv: testAWSCloudwatchResolvedEnv, AuthParams: testAWSAuthentication})
+		if test.isError {
+			if err == nil {
+				t.Errorf("%v: Expected error but got success", test.settings)
+			}
+			continue
+		}
 		if err != nil {
-			t.Fatal("Could not parse metadata:", err)
+			t.Errorf("%v: Expected success but got error %s", test.settings, err)
+			continue
 		}
-		mockAWSCloudwatchScaler := awsCloudwatchScaler{meta}
+		if meta.metricCollectionTime != test.metricCollectionTime || meta.metricStatPeriod != test.metricStatPeriod {
+			t.Errorf("%v: Expected %d and %d seconds but got %d and %d", test.settings, test.metricCollectionTime, test.metricStatPeriod, meta.metricCollectionTime, meta.metricStatPeriod)
+		}
+	}
+}
 
-		metricSpec := mockAWSCloudwatchScaler.GetMetricSpecForScaling(ctx)
+func TestCloudwatchParseMaxMetricCollectionTime(t *testing.T) {
+	tests := []struct {
+		settings map[string]string
+		isError  bool
+	}{
+		{map[string]string{"metricCollectionTime": "86400"}, false},
+		{map[string]string{"metricCollectionTime": "1209600"}, true},
+		{map[string]string{"metricCollectionTimeDuration": "336h"}, true},
+		{map[string]string{"metricCollectionTime": "1209600", "maxMetricCollectionTime": "1209600"}, false},
+		{map[string]string{"metricCollectionTime": "3600", "maxMetricCollectionTimeDuration": "30m"}, true},
+		{map[string]string{"maxMetricCollectionTime": "a day"}, true},
+	}
+	for _, test := range tests {
+		metadata := newTestAWSCloudwatchMetadata(nil)
+		for k, v := range test.settings {
+			metadata[k] = v
+		}
+		_, err := parseAwsCloudwatchMetadata(&ScalerConfig{TriggerMetadata: metadata, ResolvedEnv: testAWSCloudwatchResolvedEnv, AuthParams: testAWSAuthentication})
+		if test.isError && err == nil {
+			t.Errorf("%v: Expected error but got success", test.settings)
+		}
+		if !test.isError && err != nil {
+			t.Errorf("%v: Expected success but got error %s", test.settings, err)
+		}
+	}
+}
+
+func TestCloudwatchParseSearch(t *testing.T) {
+	tests := []struct {
+		settings   map[string]string
+		expression string
+		isError    bool
+	}{
+		{map[string]string{"search": "{AWS/SQS,QueueName} ApproximateNumberOfMessagesVisible"}, "SUM(SEARCH('{AWS/SQS,QueueName} ApproximateNumberOfMessagesVisible', 'Average', 300))", false},
+		{map[string]string{"search": "{AWS/SQS,QueueName} ApproximateNumberOfMessagesVisible", "searchAggregation": "MAX", "metricStat": "Maximum", "metricStatPeriod": "60"}, "MAX(SEARCH('{AWS/SQS,QueueName} ApproximateNumberOfMessagesVisible', 'Maximum', 60))", false},
+		{map[string]string{"search": ""}, "", true},
+		{map[string]string{"search": " "}, "", true},
+		{map[string]string{"search": "{AWS/SQS,QueueName} QueueName='keda'"}, "", true},
+		{map[string]string{"search": "{AWS/SQS,QueueName} ApproximateNumberOfMessagesVisible", "searchAggregation": "Sum"}, "", true},
+		{map[string]string{"search": "{AWS/SQS,QueueName} ApproximateNumberOfMessagesVisible", "expression": "SELECT MAX(ApproximateNumberOfMessagesVisible) FROM \"AWS/SQS\""}, "", true},
+	}
+	for _, test := range tests {
+		metadata := map[string]string{
+			"targetMetricValue": "2",
+			"minMetricValue":    "0",
+			"awsRegion":         "eu-west-1",
+		}
+		for k, v := range test.settings {
+			metadata[k] = v
+		}
+		meta, err := parseAwsCloudwatchMetadata(&ScalerConfig{TriggerMetadata: metadata, ResolvedEnv: testAWSCloudwatchResolvedEnv, AuthParams: testAWSAuthentication})
+		if test.isError {
+			if err == nil {
+				t.Errorf("%v: Expected error but got success", test.settings)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: Expected success but got error %s", test.settings, err)
+			continue
+		}
+
+		query := (&awsCloudwatchScaler{metadata: meta}).getMetricDataQueries()[0]
+		if aws.StringValue(query.Expression) != test.expression {
+			t.Errorf("%v: Expected the expression %s but got %s", test.settings, test.expression, aws.StringValue(query.Expression))
+		}
+	}
+}
+
+func TestCloudwatchParseIgnoreNullValues(t *testing.T) {
+	meta := parseTestAWSCloudwatchMetadata(t, newTestAWSCloudwatchMetadata(nil))
+	if !meta.ignoreNullValues {
+		t.Error("Expected ignoreNullValues to default to true")
+	}
+
+	meta = parseTestAWSCloudwatchMetadata(t, newTestAWSCloudwatchMetadata(map[string]string{"ignoreNullValues": "false"}))
+	if meta.ignoreNullValues {
+		t.Error("Expected ignoreNullValues to be false")
+	}
+}
+
+func TestCloudwatchParseAwsEndpoint(t *testing.T) {
+	meta := parseTestAWSCloudwatchMetadata(t, newTestAWSCloudwatchMetadata(nil))
+	if meta.awsEndpoint != "" {
+		t.Error("Expected no awsEndpoint by default")
+	}
+
+	meta = parseTestAWSCloudwatchMetadata(t, newTestAWSCloudwatchMetadata(map[string]string{"awsEndpoint": "http://localstack:4566"}))
+	if meta.awsEndpoint != "http://localstack:4566" {
+		t.Errorf("Expected awsEndpoint to be overridden but got %s", meta.awsEndpoint)
+	}
+
+	clients, err := createCloudwatchClients(meta, nil)
+	if err != nil {
+		t.Fatal("Could not create client:", err)
+	}
+	if endpoint := clients[0].(*cloudwatch.CloudWatch).Endpoint; endpoint != "http://localstack:4566" {
+		t.Errorf("Expected the client to use the overridden endpoint but got %s", endpoint)
+	}
+}
+
+func TestCloudwatchUseFIPS(t *testing.T) {
+	tests := []struct {
+		region   string
+		endpoint string
+	}{
+		// the GovCloud endpoints are FIPS validated
+		{"us-gov-west-1", "https://monitoring.us-gov-west-1.amazonaws.com"},
+		{"us-east-1", "https://monitoring-fips.us-east-1.amazonaws.com"},
+		// no FIPS endpoint, the default one is used
+		{"eu-west-1", "https://monitoring.eu-west-1.amazonaws.com"},
+	}
+	for _, test := range tests {
+		metadata := newTestAWSCloudwatchMetadata(nil)
+		metadata["awsRegion"] = test.region
+		metadata["useFIPS"] = "true"
+		meta := parseTestAWSCloudwatchMetadata(t, metadata)
+		if !meta.useFIPS {
+			t.Errorf("%s: Expected useFIPS to be set", test.region)
+		}
+
+		clients, err := createCloudwatchClients(meta, nil)
+		if err != nil {
+			t.Fatal("Could not create client:", err)
+		}
+		if endpoint := clients[0].(*cloudwatch.CloudWatch).Endpoint; endpoint != test.endpoint {
+			t.Errorf("%s: Expected endpoint %s but got %s", test.region, test.endpoint, endpoint)
+		}
+	}
+
+	metadata := newTestAWSCloudwatchMetadata(map[string]string{"awsEndpoint": "http://localstack:4566"})
+	metadata["useFIPS"] = "true"
+	if _, err := parseAwsCloudwatchMetadata(&ScalerConfig{TriggerMetadata: metadata, ResolvedEnv: testAWSCloudwatchResolvedEnv, AuthParams: testAWSAuthentication}); err == nil {
+		t.Error("Expected an error for useFIPS with awsEndpoint")
+	}
+}
+
+func TestCloudwatchParseDimensions(t *testing.T) {
+	meta := parseTestAWSCloudwatchMetadata(t, map[string]string{
+		"namespace":         "AWS/SNS",
+		"dimensions":        `[{"name": "TopicName", "value": "keda"}, {"name": "Tag", "value": "team=a;env=b"}]`,
+		"metricName":        "NumberOfMessagesPublished",
+		"targetMetricValue": "2",
+		"minMetricValue":    "0",
+		"awsRegion":         "eu-west-1",
+	})
+	if len(meta.dimensionName) != 2 || meta.dimensionName[1] != "Tag" {
+		t.Errorf("Expected two dimension names but got %v", meta.dimensionName)
+	}
+	if len(meta.dimensionValue) != 2 || meta.dimensionValue[1] != "team=a;env=b" {
+		t.Errorf("Expected the dimension value with a semicolon to be kept but got %v", meta.dimensionValue)
+	}
+}
+
+func TestAWSCloudwatchCredentials(t *testing.T) {
+	sess, err := session.NewSession(&aws.Config{Region: aws.String("eu-west-1")})
+	if err != nil {
+		t.Fatal("Could not create session:", err)
+	}
+
+	creds := getCloudwatchCredentials(sess, awsAuthorizationMetadata{
+		podIdentityOwner:   true,
+		awsAccessKeyID:     testAWSCloudwatchAccessKeyID,
+		awsSecretAccessKey: testAWSCloudwatchSecretAccessKey,
+	})
+	value, err := creds.Get()
+	if err != nil {
+		t.Fatal("Could not get credentials:", err)
+	}
+	if value.ProviderName != credentials.StaticProviderName || value.AccessKeyID != testAWSCloudwatchAccessKeyID {
+		t.Errorf("Expected static credentials but got %s", value.ProviderName)
+	}
+
+	if creds := getCloudwatchCredentials(sess, awsAuthorizationMetadata{podIdentityOwner: false}); creds != nil {
+		t.Error("Expected the default credential chain for the operator identity")
+	}
+
+	os.Setenv("AWS_ROLE_ARN", "arn:aws:iam::123456789012:role/keda")
+	os.Setenv("AWS_WEB_IDENTITY_TOKEN_FILE", "/nonexistent/token")
+	defer os.Unsetenv("AWS_ROLE_ARN")
+	defer os.Unsetenv("AWS_WEB_IDENTITY_TOKEN_FILE")
+
+	creds = getCloudwatchCredentials(sess, awsAuthorizationMetadata{podIdentityOwner: false})
+	if creds == nil {
+		t.Fatal("Expected web identity credentials for the operator identity")
+	}
+	// the token file is read before STS is called
+	_, err = creds.Get()
+	if aerr, ok := err.(awserr.Error); !ok || aerr.Code() != stscreds.ErrCodeWebIdentity {
+		t.Errorf("Expected a web identity error but got %v", err)
+	}
+
+	// the pod identity ignores the web identity environment
+	creds = getCloudwatchCredentials(sess, awsAuthorizationMetadata{
+		podIdentityOwner:   true,
+		awsAccessKeyID:     testAWSCloudwatchAccessKeyID,
+		awsSecretAccessKey: testAWSCloudwatchSecretAccessKey,
+	})
+	value, err = creds.Get()
+	if err != nil || value.ProviderName != credentials.StaticProviderName {
+		t.Errorf("Expected static credentials but got %v", err)
+	}
+}
+
+func TestAWSCloudwatchAssumeRoleExternalID(t *testing.T) {
+	authParams := map[string]string{
+		"awsRoleArn":        testAWSCloudwatchRoleArn,
+		"awsRoleExternalId": "keda-external-id",
+	}
+	metadata := map[string]string{
+		"namespace":         "AWS/SQS",
+		"dimensionName":     "QueueName",
+		"dimensionValue":    "keda",
+		"metricName":        "ApproximateNumberOfMessagesVisible",
+		"targetMetricValue": "2",
+		"minMetricValue":    "0",
+		"awsRegion":         "eu-west-1",
+	}
+	meta, err := parseAwsCloudwatchMetadata(&ScalerConfig{TriggerMetadata: metadata, ResolvedEnv: testAWSCloudwatchResolvedEnv, AuthParams: authParams})
+	if err != nil {
+		t.Fatal("Could not parse metadata:", err)
+	}
+
+	provider := &stscreds.AssumeRoleProvider{}
+	for _, option := range getAssumeRoleOptions(meta.awsAuthorization) {
+		option(provider)
+	}
+	if provider.ExternalID == nil || *provider.ExternalID != "keda-external-id" {
+		t.Error("Expected the external ID to be set on the assume role provider")
+	}
+
+	delete(authParams, "awsRoleExternalId")
+	meta, err = parseAwsCloudwatchMetadata(&ScalerConfig{TriggerMetadata: metadata, ResolvedEnv: testAWSCloudwatchResolvedEnv, AuthParams: authParams})
+	if err != nil {
+		t.Fatal("Could not parse metadata:", err)
+	}
+	if options := getAssumeRoleOptions(meta.awsAuthorization); len(options) != 0 {
+		t.Error("Expected no assume role options without an external ID")
+	}
+}
+
+func TestCloudwatchParseAwsRegion(t *testing.T) {
+	tests := []struct {
+		region  string
+		isError bool
+	}{
+		{"eu-west-1", false},
+		{"ap-southeast-2", false},
+		{"us-gov-west-1", false},
+		{"cn-north-1", false},
+		{"us-isob-east-1", false},
+		{"us-east1", true},
+		{"US-EAST-1", true},
+		{"useast-1", true},
+	}
+	for _, test := range tests {
+		metadata := newTestAWSCloudwatchMetadata(nil)
+		metadata["awsRegion"] = test.region
+		_, err := parseAwsCloudwatchMetadata(&ScalerConfig{TriggerMetadata: metadata, ResolvedEnv: testAWSCloudwatchResolvedEnv, AuthParams: testAWSAuthentication})
+		if test.isError && err == nil {
+			t.Errorf("%s: Expected error but got success", test.region)
+		}
+		if !test.isError && err != nil {
+			t.Errorf("%s: Expected success but got error %s", test.region, err)
+		}
+	}
+}
+
+func TestAWSCloudwatchGetMetricSpecForScaling(t *testing.T) {
+	for _, testData := range awsCloudwatchMetricIdentifiers {
+		ctx := context.Background()
+		meta, err := parseAwsCloudwatchMetadata(&ScalerConfig{TriggerMetadata: testData.metadataTestData.metadata, ResolvedEnv: testAWSCloudwatchResolvedEnv, AuthParams: testData.metadataTestData.authParams, ScalerIndex: testData.scalerIndex})
+		if err != nil {
+			t.Fatal("Could not parse metadata:", err)
+		}
+		mockAWSCloudwatchScaler := awsCloudwatchScaler{metadata: meta}
+
+		metricSpec := mockAWSCloudwatchScaler.GetMetricSpecForScaling(ctx)
 		metricName := metricSpec[0].External.Metric.Name
 		if metricName != testData.name {
 			t.Error("Wrong External metric source name:", metricName)
 		}
 	}
 }
+
+func TestAWSCloudwatchFractionalTargetMetricValue(t *testing.T) {
+	meta := parseTestAWSCloudwatchMetadata(t, newTestAWSCloudwatchMetadata(map[string]string{"targetMetricValue": "0.5"}))
+	metricSpec := (&awsCloudwatchScaler{metadata: meta}).GetMetricSpecForScaling(context.Background())
+	target := metricSpec[0].External.Target.AverageValue
+	if target.IsZero() {
+		t.Error("Expected a fractional target to be a non-zero quantity")
+	}
+	if target.MilliValue() != 500 {
+		t.Errorf("Expected a target of 500m but got %s", target.String())
+	}
+
+	meta = parseTestAWSCloudwatchMetadata(t, newTestAWSCloudwatchMetadata(nil))
+	metricSpec = (&awsCloudwatchScaler{metadata: meta}).GetMetricSpecForScaling(context.Background())
+	target = metricSpec[0].External.Target.AverageValue
+	if target.Cmp(*resource.NewQuantity(2, resource.DecimalSI)) != 0 || target.String() != "2" {
+		t.Errorf("Expected an integer target to be unchanged but got %s", target.String())
+	}
+	// ScaledJobs and the HPA read integer targets with AsInt64 and Value
+	if value, ok := target.AsInt64(); !ok || value != 2 || target.Value() != 2 {
+		t.Errorf("Expected an integer target to be readable as 2 but got %d (%t)", value, ok)
+	}
+}
+
+func TestAWSCloudwatchMetricTargetType(t *testing.T) {
+	tests := []struct {
+		metricTargetType string
+		expectedType     v2beta2.MetricTargetType
+		isError          bool
+	}{
+		{"", v2beta2.AverageValueMetricType, false},
+		{"AverageValue", v2beta2.AverageValueMetricType, false},
+		{"Value", v2beta2.ValueMetricType, false},
+		{"Utilization", "", true},
+	}
+	for _, test := range tests {
+		metadata := newTestAWSCloudwatchMetadata(nil)
+		metadata["metricTargetType"] = test.metricTargetType
+		meta, err := parseAwsCloudwatchMetadata(&ScalerConfig{TriggerMetadata: metadata, ResolvedEnv: testAWSCloudwatchResolvedEnv, AuthParams: testAWSAuthentication})
+		if test.isError {
+			if err == nil {
+				t.Errorf("%s: Expected error but got success", test.metricTargetType)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal("Could not parse metadata:", err)
+		}
+
+		target := (&awsCloudwatchScaler{metadata: meta}).GetMetricSpecForScaling(context.Background())[0].External.Target
+		if target.Type != test.expectedType {
+			t.Errorf("%s: Expected target type %s but got %s", test.metricTargetType, test.expectedType, target.Type)
+		}
+		set, unset := target.AverageValue, target.Value
+		if test.expectedType == v2beta2.ValueMetricType {
+			set, unset = target.Value, target.AverageValue
+		}
+		if unset != nil {
+			t.Errorf("%s: Expected only one target value to be set", test.metricTargetType)
+		}
+		if set == nil || set.MilliValue() != 2000 {
+			t.Errorf("%s: Expected a target of 2 but got %v", test.metricTargetType, set)
+		}
+	}
+}
+
+func TestAWSCloudwatchFractionalMetricValue(t *testing.T) {
+	meta := parseTestAWSCloudwatchMetadata(t, newTestAWSCloudwatchMetadata(nil))
+	meta.minMetricValue = 0.5
+	client := &mockCloudwatch{
+		output: &cloudwatch.GetMetricDataOutput{
+			MetricDataResults: []*cloudwatch.MetricDataResult{
+				{Values: []*float64{aws.Float64(0.73)}, Timestamps: []*time.Time{aws.Time(time.Now())}},
+			},
+		},
+	}
+	scaler := awsCloudwatchScaler{metadata: meta, cwClients: []cloudwatchClient{client}}
+
+	metrics, err := scaler.GetMetrics(context.Background(), "metric", nil)
+	if err != nil {
+		t.Fatal("Could not get metrics:", err)
+	}
+	if metrics[0].Value.MilliValue() != 730 {
+		t.Errorf("Expected the metric value 730m but got %s", metrics[0].Value.String())
+	}
+
+	client.output.MetricDataResults[0].Values = []*float64{aws.Float64(7)}
+	metrics, err = scaler.GetMetrics(context.Background(), "metric", nil)
+	if err != nil {
+		t.Fatal("Could not get metrics:", err)
+	}
+	if value, ok := metrics[0].Value.AsInt64(); !ok || value != 7 || metrics[0].Value.Value() != 7 {
+		t.Errorf("Expected an integer metric value to be readable as 7 but got %s", metrics[0].Value.String())
+	}
+
+	// 0.73 would be truncated to 0 if IsActive compared quantities instead of the raw value
+	isActive, err := scaler.IsActive(context.Background())
+	if err != nil {
+		t.Fatal("Could not get active state:", err)
+	}
+	if !isActive {
+		t.Error("Expected the scaler to be active for a value above minMetricValue")
+	}
+}
+
+func TestAWSCloudwatchActivationTargetValue(t *testing.T) {
+	meta := parseTestAWSCloudwatchMetadata(t, newTestAWSCloudwatchMetadata(nil))
+	if meta.activationTargetValue != meta.minMetricValue {
+		t.Errorf("Expected activationTargetValue to default to minMetricValue but got %v", meta.activationTargetValue)
+	}
+
+	meta = parseTestAWSCloudwatchMetadata(t, newTestAWSCloudwatchMetadata(map[string]string{"activationTargetValue": "5"}))
+
+	tests := []struct {
+		value    float64
+		isActive bool
+	}{
+		{0, false},
+		{5, false},
+		{5.5, true},
+	}
+	for _, test := range tests {
+		client := &mockCloudwatch{
+			output: &cloudwatch.GetMetricDataOutput{
+				MetricDataResults: []*cloudwatch.MetricDataResult{
+					{Values: []*float64{aws.Float64(test.value)}, Timestamps: []*time.Time{aws.Time(time.Now())}},
+				},
+			},
+		}
+		scaler := awsCloudwatchScaler{metadata: meta, cwClients: []cloudwatchClient{client}}
+		isActive, err := scaler.IsActive(context.Background())
+		if err != nil {
+			t.Fatal("Could not get active state:", err)
+		}
+		if isActive != test.isActive {
+			t.Errorf("Expected isActive %v for value %v but got %v", test.isActive, test.value, isActive)
+		}
+	}
+}
+
+func TestAWSCloudwatchGetMetricsReusesActiveValue(t *testing.T) {
+	meta := parseTestAWSCloudwatchMetadata(t, newTestAWSCloudwatchMetadata(nil))
+	client := &mockCloudwatch{
+		output: &cloudwatch.GetMetricDataOutput{
+			MetricDataResults: []*cloudwatch.MetricDataResult{
+				{Values: []*float64{aws.Float64(10)}, Timestamps: []*time.Time{aws.Time(time.Now())}},
+			},
+		},
+	}
+	scaler := awsCloudwatchScaler{metadata: meta, cwClients: []cloudwatchClient{client}}
+
+	if _, err := scaler.IsActive(context.Background()); err != nil {
+		t.Fatal("Could not get active state:", err)
+	}
+	metrics, err := scaler.GetMetrics(context.Background(), "metric", nil)
+	if err != nil {
+		t.Fatal("Could not get metrics:", err)
+	}
+	if client.calls != 1 {
+		t.Errorf("Expected GetMetrics to reuse the value fetched by IsActive but got %d calls", client.calls)
+	}
+	if metrics[0].Value.MilliValue() != 10000 {
+		t.Errorf("Expected the value fetched by IsActive but got %v", metrics[0].Value.AsApproximateFloat64())
+	}
+
+	// the value is only used once
+	if _, err := scaler.GetMetrics(context.Background(), "metric", nil); err != nil {
+		t.Fatal("Could not get metrics:", err)
+	}
+	if client.calls != 2 {
+		t.Errorf("Expected the second GetMetrics call to query cloudwatch but got %d calls", client.calls)
+	}
+}
+
+func TestAWSCloudwatchMetricDataMessages(t *testing.T) {
+	meta := parseTestAWSCloudwatchMetadata(t, newTestAWSCloudwatchMetadata(nil))
+	meta.metricUnit = cloudwatch.StandardUnitCount
+	now := time.Now()
+	client := &mockCloudwatch{
+		output: &cloudwatch.GetMetricDataOutput{
+			Messages: []*cloudwatch.MessageData{{Code: aws.String("Warning"), Value: aws.String("the query was throttled")}},
+			MetricDataResults: []*cloudwatch.MetricDataResult{
+				{
+					Id:         aws.String("c1"),
+					Values:     []*float64{aws.Float64(10)},
+					Timestamps: []*time.Time{aws.Time(now)},
+					Messages:   []*cloudwatch.MessageData{{Code: aws.String("PartialData"), Value: aws.String("not all datapoints were returned")}},
+				},
+			},
+		},
+	}
+	scaler := awsCloudwatchScaler{metadata: meta, cwClients: []cloudwatchClient{client}}
+
+	value, err := scaler.getCloudwatchValue(context.Background())
+	if err != nil {
+		t.Fatal("Could not get metrics:", err)
+	}
+	if value.value != 10 || !value.timestamp.Equal(now) || value.unit != cloudwatch.StandardUnitCount {
+		t.Errorf("Expected 10 %s at %v but got %v %s at %v", cloudwatch.StandardUnitCount, now, value.value, value.unit, value.timestamp)
+	}
+	expected := []string{"Warning: the query was throttled", "c1 PartialData: not all datapoints were returned"}
+	if len(value.messages) != len(expected) {
+		t.Fatalf("Expected messages %v but got %v", expected, value.messages)
+	}
+	for i := range expected {
+		if value.messages[i] != expected[i] {
+			t.Errorf("Expected message %s but got %s", expected[i], value.messages[i])
+		}
+	}
+}
+
+func TestAWSCloudwatchMetricNameDimensionOrder(t *testing.T) {
+	names := []string{}
+	for _, dimensions := range [][]string{{"QueueName;Region", "keda;eu"}, {"Region;QueueName", "eu;keda"}} {
+		metadata := newTestAWSCloudwatchMetadata(nil)
+		metadata["dimensionName"] = dimensions[0]
+		metadata["dimensionValue"] = dimensions[1]
+		meta := parseTestAWSCloudwatchMetadata(t, metadata)
+		scaler := &awsCloudwatchScaler{metadata: meta}
+		names = append(names, scaler.GetMetricSpecForScaling(context.Background())[0].External.Metric.Name)
+
+		// the dimensions are still sent in the given order
+		query := scaler.getMetricDataQuery()
+		if *query.MetricStat.Metric.Dimensions[0].Name != meta.dimensionName[0] {
+			t.Error("Expected the dimensions to keep their order in the query")
+		}
+	}
+	if names[0] != names[1] {
+		t.Errorf("Expected the same metric name for permuted dimensions but got %s and %s", names[0], names[1])
+	}
+	if names[0] != "s0-aws-cloudwatch-AWS-SQS-QueueName-keda" {
+		t.Errorf("Expected the name to use the first dimension by name but got %s", names[0])
+	}
+}
+
+func TestAWSCloudwatchMetricDataQuery(t *testing.T) {
+	meta := parseTestAWSCloudwatchMetadata(t, newTestAWSCloudwatchExpressionMetadata(nil))
+	query := (&awsCloudwatchScaler{metadata: meta}).getMetricDataQuery()
+	if query.MetricStat != nil {
+		t.Error("Expected no MetricStat when an expression is given")
+	}
+	if query.Expression == nil || *query.Expression != testAWSCloudwatchExpression {
+		t.Error("Expected the expression to be set on the query")
+	}
+
+	meta = parseTestAWSCloudwatchMetadata(t, newTestAWSCloudwatchMetadata(nil))
+	query = (&awsCloudwatchScaler{metadata: meta}).getMetricDataQuery()
+	if query.Expression != nil {
+		t.Error("Expected no Expression when an expression is not given")
+	}
+	if query.MetricStat == nil || *query.MetricStat.Metric.MetricName != "ApproximateNumberOfMessagesVisible" {
+		t.Error("Expected the MetricStat to be set on the query")
+	}
+	if query.MetricStat.Unit != nil {
+		t.Error("Expected no Unit when metricUnit is not given")
+	}
+
+	meta = parseTestAWSCloudwatchMetadata(t, newTestAWSCloudwatchMetadata(map[string]string{"metricUnit": "Count"}))
+	query = (&awsCloudwatchScaler{metadata: meta}).getMetricDataQuery()
+	if query.MetricStat.Unit == nil || *query.MetricStat.Unit != "Count" {
+		t.Error("Expected the Unit to be propagated to the query")
+	}
+
+	meta = parseTestAWSCloudwatchMetadata(t, newTestAWSCloudwatchMetadata(map[string]string{"metricStat": "p95", "metricStatPeriod": "120"}))
+	query = (&awsCloudwatchScaler{metadata: meta}).getMetricDataQuery()
+	if *query.MetricStat.Stat != "p95" || *query.MetricStat.Period != 120 {
+		t.Errorf("Expected a p95 query with a 120s period but got %s with %ds", *query.MetricStat.Stat, *query.MetricStat.Period)
+	}
+}
+
+func TestAWSCloudwatchGetLatestMetricDataValue(t *testing.T) {
+	now := time.Now()
+	result := &cloudwatch.MetricDataResult{
+		Values:     []*float64{aws.Float64(1), aws.Float64(3), aws.Float64(2)},
+		Timestamps: []*time.Time{aws.Time(now.Add(-2 * time.Minute)), aws.Time(now), aws.Time(now.Add(-1 * time.Minute))},
+	}
+	value, _, ok := getLatestMetricDataValue(result)
+	if !ok {
+		t.Fatal("Expected a datapoint to be found")
+	}
+	if value != 3 {
+		t.Errorf("Expected the most recent datapoint 3 but got %v", value)
+	}
+
+	_, _, ok = getLatestMetricDataValue(&cloudwatch.MetricDataResult{})
+	if ok {
+		t.Error("Expected no datapoint to be found for an empty result")
+	}
+}
+
+func TestAWSCloudwatchReusesClient(t *testing.T) {
+	scaler, err := NewAwsCloudwatchScaler(&ScalerConfig{TriggerMetadata: newTestAWSCloudwatchMetadata(nil), ResolvedEnv: testAWSCloudwatchResolvedEnv, AuthParams: testAWSAuthentication})
+	if err != nil {
+		t.Fatal("Could not create scaler:", err)
+	}
+	cwScaler := scaler.(*awsCloudwatchScaler)
+	if len(cwScaler.cwClients) != 1 {
+		t.Fatal("Expected the cloudwatch client to be created with the scaler")
+	}
+
+	client := &mockCloudwatch{
+		output: &cloudwatch.GetMetricDataOutput{
+			MetricDataResults: []*cloudwatch.MetricDataResult{
+				{Values: []*float64{aws.Float64(10)}, Timestamps: []*time.Time{aws.Time(time.Now())}},
+			},
+		},
+	}
+	cwScaler.cwClients = []cloudwatchClient{client}
+	for i := 0; i < 2; i++ {
+		if _, _, err := cwScaler.GetCloudwatchMetrics(context.Background()); err != nil {
+			t.Fatal("Could not get metrics:", err)
+		}
+	}
+	if client.calls != 2 || cwScaler.cwClients[0] != client {
+		t.Error("Expected the same client to be reused across calls")
+	}
+}
+
+func TestAWSCloudwatchClose(t *testing.T) {
+	scaler, err := NewAwsCloudwatchScaler(&ScalerConfig{TriggerMetadata: newTestAWSCloudwatchMetadata(nil), ResolvedEnv: testAWSCloudwatchResolvedEnv, AuthParams: testAWSAuthentication})
+	if err != nil {
+		t.Fatal("Could not create scaler:", err)
+	}
+	cwScaler := scaler.(*awsCloudwatchScaler)
+
+	if err := cwScaler.Close(context.Background()); err != nil {
+		t.Fatal("Could not close scaler:", err)
+	}
+	if cwScaler.cwClients != nil || cwScaler.httpClient != nil {
+		t.Error("Expected the clients to be released on Close")
+	}
+
+	_, _, err = cwScaler.GetCloudwatchMetrics(context.Background())
+	if err == nil || err.Error() != "cloudwatch client is closed" {
+		t.Errorf("Expected a closed client error but got %v", err)
+	}
+
+	// closing twice is safe
+	if err := cwScaler.Close(context.Background()); err != nil {
+		t.Error("Expected a second Close to succeed:", err)
+	}
+}
+
+func TestAWSCloudwatchMultipleQueriesReturnsMax(t *testing.T) {
+	meta := parseTestAWSCloudwatchMetadata(t, newTestAWSCloudwatchQueriesMetadata())
+	client := &mockCloudwatch{
+		output: &cloudwatch.GetMetricDataOutput{
+			MetricDataResults: []*cloudwatch.MetricDataResult{
+				{Id: aws.String("cpu"), Values: []*float64{aws.Float64(40)}, Timestamps: []*time.Time{aws.Time(time.Now())}},
+				{Id: aws.String("queue"), Values: []*float64{aws.Float64(75)}, Timestamps: []*time.Time{aws.Time(time.Now())}},
+			},
+		},
+	}
+	scaler := awsCloudwatchScaler{metadata: meta, cwClients: []cloudwatchClient{client}}
+
+	value, _, err := scaler.GetCloudwatchMetrics(context.Background())
+	if err != nil {
+		t.Fatal("Could not get metrics:", err)
+	}
+	if value != 75 {
+		t.Errorf("Expected the max value 75 but got %v", value)
+	}
+	if len(scaler.getMetricDataQueries()) != 2 {
+		t.Error("Expected both queries to be sent")
+	}
+}
+
+func TestAWSCloudwatchContextDeadline(t *testing.T) {
+	meta := parseTestAWSCloudwatchMetadata(t, newTestAWSCloudwatchMetadata(nil))
+	client := &mockCloudwatch{
+		output:  &cloudwatch.GetMetricDataOutput{},
+		latency: time.Minute,
+	}
+	scaler := awsCloudwatchScaler{metadata: meta, cwClients: []cloudwatchClient{client}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, _, err := scaler.GetCloudwatchMetrics(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected a deadline exceeded error but got %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Error("Expected the call to be aborted when the context deadline is reached")
+	}
+}
+
+func TestAWSCloudwatchMetricResultAggregation(t *testing.T) {
+	output := &cloudwatch.GetMetricDataOutput{
+		MetricDataResults: []*cloudwatch.MetricDataResult{
+			{Id: aws.String("c1"), Values: []*float64{aws.Float64(2)}, Timestamps: []*time.Time{aws.Time(time.Now())}},
+			{Id: aws.String("c2"), Values: []*float64{aws.Float64(6)}, Timestamps: []*time.Time{aws.Time(time.Now())}},
+		},
+	}
+	tests := []struct {
+		aggregation string
+		expected    float64
+	}{
+		{"first", 2},
+		{"max", 6},
+		{"sum", 8},
+		{"avg", 4},
+	}
+	for _, test := range tests {
+		meta := &awsCloudwatchMetadata{metricResultAggregation: test.aggregation}
+		value, _, ok := (&awsCloudwatchScaler{metadata: meta}).getMetricValue(output)
+		if !ok {
+			t.Fatalf("%s: Expected a value to be found", test.aggregation)
+		}
+		if value != test.expected {
+			t.Errorf("%s: Expected %v but got %v", test.aggregation, test.expected, value)
+		}
+	}
+
+	meta := parseTestAWSCloudwatchMetadata(t, newTestAWSCloudwatchMetadata(nil))
+	if meta.metricResultAggregation != "first" {
+		t.Errorf("Expected metricResultAggregation to default to first but got %s", meta.metricResultAggregation)
+	}
+}
+
+func TestAWSCloudwatchGetMetricsForWindow(t *testing.T) {
+	meta := parseTestAWSCloudwatchMetadata(t, newTestAWSCloudwatchMetadata(nil))
+	client := &mockCloudwatch{
+		output: &cloudwatch.GetMetricDataOutput{
+			MetricDataResults: []*cloudwatch.MetricDataResult{
+				{Values: []*float64{aws.Float64(4)}, Timestamps: []*time.Time{aws.Time(time.Now())}},
+			},
+		},
+	}
+	scaler := awsCloudwatchScaler{metadata: meta, cwClients: []cloudwatchClient{client}}
+
+	startTime := time.Date(2021, 10, 1, 10, 0, 0, 0, time.UTC)
+	endTime := time.Date(2021, 10, 1, 11, 0, 0, 0, time.UTC)
+	value, _, err := scaler.GetCloudwatchMetricsForWindow(context.Background(), startTime, endTime)
+	if err != nil {
+		t.Fatal("Could not get metrics:", err)
+	}
+	if value != 4 {
+		t.Errorf("Expected 4 but got %v", value)
+	}
+	if !client.input.StartTime.Equal(startTime) || !client.input.EndTime.Equal(endTime) {
+		t.Errorf("Expected the window to be used for the query but got %v - %v", *client.input.StartTime, *client.input.EndTime)
+	}
+}
+
+func TestAWSCloudwatchMetricAggregationSum(t *testing.T) {
+	meta := parseTestAWSCloudwatchMetadata(t, newTestAWSCloudwatchMetadata(map[string]string{"metricName": "NumberOfMessagesSent", "metricStat": "Sum", "metricStatPeriod": "60", "metricAggregation": "sum"}))
+	now := time.Now()
+	client := &mockCloudwatch{
+		output: &cloudwatch.GetMetricDataOutput{
+			MetricDataResults: []*cloudwatch.MetricDataResult{
+				{
+					Values: []*float64{aws.Float64(1), aws.Float64(2), aws.Float64(3), aws.Float64(4), aws.Float64(5)},
+					Timestamps: []*time.Time{
+						aws.Time(now), aws.Time(now.Add(-1 * time.Minute)), aws.Time(now.Add(-2 * time.Minute)),
+						aws.Time(now.Add(-3 * time.Minute)), aws.Time(now.Add(-4 * time.Minute)),
+					},
+				},
+			},
+		},
+	}
+	scaler := awsCloudwatchScaler{metadata: meta, cwClients: []cloudwatchClient{client}}
+
+	value, _, err := scaler.GetCloudwatchMetrics(context.Background())
+	if err != nil {
+		t.Fatal("Could not get metrics:", err)
+	}
+	if value != 15 {
+		t.Errorf("Expected the sum of the window 15 but got %v", value)
+	}
+
+	scaler.metadata.metricAggregation = "last"
+	value, _, err = scaler.GetCloudwatchMetrics(context.Background())
+	if err != nil {
+		t.Fatal("Could not get metrics:", err)
+	}
+	if value != 1 {
+		t.Errorf("Expected the newest value 1 but got %v", value)
+	}
+}
+
+func TestAWSCloudwatchScanByTimestampDescending(t *testing.T) {
+	meta := parseTestAWSCloudwatchMetadata(t, newTestAWSCloudwatchMetadata(nil))
+	now := time.Now()
+	client := &mockCloudwatch{
+		output: &cloudwatch.GetMetricDataOutput{
+			MetricDataResults: []*cloudwatch.MetricDataResult{
+				{
+					Values:     []*float64{aws.Float64(7), aws.Float64(3), aws.Float64(1)},
+					Timestamps: []*time.Time{aws.Time(now), aws.Time(now.Add(-5 * time.Minute)), aws.Time(now.Add(-10 * time.Minute))},
+				},
+			},
+		},
+	}
+	scaler := awsCloudwatchScaler{metadata: meta, cwClients: []cloudwatchClient{client}}
+
+	value, _, err := scaler.GetCloudwatchMetrics(context.Background())
+	if err != nil {
+		t.Fatal("Could not get metrics:", err)
+	}
+	if client.input.ScanBy == nil || *client.input.ScanBy != cloudwatch.ScanByTimestampDescending {
+		t.Error("Expected the query to scan by descending timestamps")
+	}
+	if value != 7 {
+		t.Errorf("Expected the first value 7 but got %v", value)
+	}
+}
+
+func TestAWSCloudwatchEmptyMetricDataResults(t *testing.T) {
+	meta := parseTestAWSCloudwatchMetadata(t, newTestAWSCloudwatchMetadata(nil))
+	client := &mockCloudwatch{
+		output: &cloudwatch.GetMetricDataOutput{
+			MetricDataResults: []*cloudwatch.MetricDataResult{},
+		},
+	}
+	scaler := awsCloudwatchScaler{metadata: meta, cwClients: []cloudwatchClient{client}}
+
+	value, _, err := scaler.GetCloudwatchMetrics(context.Background())
+	if err != nil {
+		t.Fatal("Expected no error when ignoring null values but got:", err)
+	}
+	if value != meta.minMetricValue {
+		t.Errorf("Expected minMetricValue but got %v", value)
+	}
+
+	scaler.metadata.ignoreNullValues = false
+	_, _, err = scaler.GetCloudwatchMetrics(context.Background())
+	if err == nil {
+		t.Error("Expected an error for empty results")
+	}
+}
+
+func TestAWSCloudwatchMetricTimestamp(t *testing.T) {
+	meta := parseTestAWSCloudwatchMetadata(t, newTestAWSCloudwatchMetadata(nil))
+	datapointTime := time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)
+	client := &mockCloudwatch{
+		output: &cloudwatch.GetMetricDataOutput{
+			MetricDataResults: []*cloudwatch.MetricDataResult{
+				{Values: []*float64{aws.Float64(3)}, Timestamps: []*time.Time{aws.Time(datapointTime)}},
+			},
+		},
+	}
+	scaler := awsCloudwatchScaler{metadata: meta, cwClients: []cloudwatchClient{client}}
+
+	metrics, err := scaler.GetMetrics(context.Background(), "metric", nil)
+	if err != nil {
+		t.Fatal("Could not get metrics:", err)
+	}
+	if !metrics[0].Timestamp.Time.Equal(datapointTime) {
+		t.Errorf("Expected the datapoint timestamp %v but got %v", datapointTime, metrics[0].Timestamp.Time)
+	}
+
+	// minMetricValue has no datapoint, the current time is used instead
+	client.output = &cloudwatch.GetMetricDataOutput{}
+	metrics, err = scaler.GetMetrics(context.Background(), "metric", nil)
+	if err != nil {
+		t.Fatal("Could not get metrics:", err)
+	}
+	if metrics[0].Timestamp.IsZero() || metrics[0].Timestamp.Time.Equal(datapointTime) {
+		t.Errorf("Expected the current time but got %v", metrics[0].Timestamp.Time)
+	}
+}
+
+func TestAWSCloudwatchAnomalyDetectionBand(t *testing.T) {
+	meta := parseTestAWSCloudwatchMetadata(t, map[string]string{
+		"queries":                `[{"Id": "m1", "MetricStat": {"Metric": {"Namespace": "AWS/ApplicationELB", "MetricName": "RequestCount"}, "Period": 300, "Stat": "Sum"}, "ReturnData": true}, {"Id": "ad1", "Expression": "ANOMALY_DETECTION_BAND(m1, 2)", "ReturnData": true}]`,
+		"anomalyDetectionBandId": "ad1",
+		"targetMetricValue":      "100",
+		"minMetricValue":         "0",
+		"awsRegion":              "eu-west-1",
+	})
+
+	tests := []struct {
+		value    float64
+		isActive bool
+	}{
+		{120, false},
+		{180, true},
+	}
+	for _, test := range tests {
+		now := time.Now()
+		client := &mockCloudwatch{
+			output: &cloudwatch.GetMetricDataOutput{
+				MetricDataResults: []*cloudwatch.MetricDataResult{
+					{Id: aws.String("m1"), Values: []*float64{aws.Float64(test.value)}, Timestamps: []*time.Time{aws.Time(now)}},
+					{Id: aws.String("ad1"), Label: aws.String("High"), Values: []*float64{aws.Float64(150)}, Timestamps: []*time.Time{aws.Time(now)}},
+					{Id: aws.String("ad1"), Label: aws.String("Low"), Values: []*float64{aws.Float64(50)}, Timestamps: []*time.Time{aws.Time(now)}},
+				},
+			},
+		}
+		scaler := awsCloudwatchScaler{metadata: meta, cwClients: []cloudwatchClient{client}}
+
+		isActive, err := scaler.IsActive(context.Background())
+		if err != nil {
+			t.Fatal("Could not get active state:", err)
+		}
+		if isActive != test.isActive {
+			t.Errorf("Expected isActive %v for value %v but got %v", test.isActive, test.value, isActive)
+		}
+
+		// the band is not part of the reported value
+		value, _, err := scaler.GetCloudwatchMetrics(context.Background())
+		if err != nil {
+			t.Fatal("Could not get metrics:", err)
+		}
+		if value != test.value {
+			t.Errorf("Expected the metric value %v but got %v", test.value, value)
+		}
+	}
+}
+
+func TestAWSCloudwatchMultipleRegions(t *testing.T) {
+	metadata := newTestAWSCloudwatchMetadata(nil)
+	metadata["awsRegion"] = "eu-west-1, us-east-1"
+
+	tests := []struct {
+		regionAggregation string
+		expected          float64
+	}{
+		{"", 10},
+		{"sum", 10},
+		{"avg", 5},
+		{"max", 7},
+	}
+	for _, test := range tests {
+		metadata["regionAggregation"] = test.regionAggregation
+		meta := parseTestAWSCloudwatchMetadata(t, metadata)
+		if len(meta.awsRegions) != 2 || meta.awsRegion != "eu-west-1" {
+			t.Fatalf("Expected two regions but got %v", meta.awsRegions)
+		}
+
+		euClient := &mockCloudwatch{
+			output: &cloudwatch.GetMetricDataOutput{
+				MetricDataResults: []*cloudwatch.MetricDataResult{
+					{Values: []*float64{aws.Float64(3)}, Timestamps: []*time.Time{aws.Time(time.Now())}},
+				},
+			},
+		}
+		usClient := &mockCloudwatch{
+			output: &cloudwatch.GetMetricDataOutput{
+				MetricDataResults: []*cloudwatch.MetricDataResult{
+					{Values: []*float64{aws.Float64(7)}, Timestamps: []*time.Time{aws.Time(time.Now())}},
+				},
+			},
+		}
+		scaler := awsCloudwatchScaler{metadata: meta, cwClients: []cloudwatchClient{euClient, usClient}}
+
+		value, _, err := scaler.GetCloudwatchMetrics(context.Background())
+		if err != nil {
+			t.Fatal("Could not get metrics:", err)
+		}
+		if value != test.expected {
+			t.Errorf("%s: Expected %v but got %v", test.regionAggregation, test.expected, value)
+		}
+		if euClient.calls != 1 || usClient.calls != 1 {
+			t.Error("Expected each region to be queried once")
+		}
+	}
+
+	metadata["awsRegion"] = "eu-west-1,us-east1"
+	metadata["regionAggregation"] = ""
+	if _, err := parseAwsCloudwatchMetadata(&ScalerConfig{TriggerMetadata: metadata, ResolvedEnv: testAWSCloudwatchResolvedEnv, AuthParams: testAWSAuthentication}); err == nil {
+		t.Error("Expected an error for a malformed region in the list")
+	}
+}
+
+func TestAWSCloudwatchSoftErrorCodes(t *testing.T) {
+	metadata := newTestAWSCloudwatchMetadata(nil)
+	metadata["minMetricValue"] = "1"
+	metadata["softErrorCodes"] = "Throttling, RequestLimitExceeded"
+	meta := parseTestAWSCloudwatchMetadata(t, metadata)
+	client := &mockCloudwatch{
+		err: awserr.New("Throttling", "Rate exceeded", nil),
+	}
+	scaler := awsCloudwatchScaler{metadata: meta, cwClients: []cloudwatchClient{client}}
+
+	value, _, err := scaler.GetCloudwatchMetrics(context.Background())
+	if err != nil {
+		t.Fatal("Expected a soft error to be ignored but got:", err)
+	}
+	if value != 1 {
+		t.Errorf("Expected minMetricValue but got %v", value)
+	}
+
+	client.err = awserr.New("AccessDenied", "Access denied", nil)
+	if _, _, err = scaler.GetCloudwatchMetrics(context.Background()); err == nil {
+		t.Error("Expected an error for a code that isn't soft")
+	}
+}
+
+func TestAWSCloudwatchSoftErrorInOneRegion(t *testing.T) {
+	metadata := newTestAWSCloudwatchMetadata(nil)
+	metadata["awsRegion"] = "eu-west-1, us-east-1"
+	metadata["minMetricValue"] = "1"
+	metadata["softErrorCodes"] = "Throttling"
+	meta := parseTestAWSCloudwatchMetadata(t, metadata)
+	euClient := &mockCloudwatch{
+		err: awserr.New("Throttling", "Rate exceeded", nil),
+	}
+	usClient := &mockCloudwatch{
+		output: &cloudwatch.GetMetricDataOutput{
+			MetricDataResults: []*cloudwatch.MetricDataResult{
+				{Values: []*float64{aws.Float64(7)}, Timestamps: []*time.Time{aws.Time(time.Now())}},
+			},
+		},
+	}
+	scaler := awsCloudwatchScaler{metadata: meta, cwClients: []cloudwatchClient{euClient, usClient}}
+
+	value, _, err := scaler.GetCloudwatchMetrics(context.Background())
+	if err != nil {
+		t.Fatal("Expected a soft error to be ignored but got:", err)
+	}
+	if value != 7 {
+		t.Errorf("Expected the value of the region without soft error but got %v", value)
+	}
+
+	usClient.err = awserr.New("Throttling", "Rate exceeded", nil)
+	value, _, err = scaler.GetCloudwatchMetrics(context.Background())
+	if err != nil {
+		t.Fatal("Expected the soft errors to be ignored but got:", err)
+	}
+	if value != 1 {
+		t.Errorf("Expected minMetricValue once every region soft-fails but got %v", value)
+	}
+}
+
+func TestAWSCloudwatchQueryWindow(t *testing.T) {
+	meta := parseTestAWSCloudwatchMetadata(t, newTestAWSCloudwatchMetadata(map[string]string{"metricEndTimeOffset": "60"}))
+	now := time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)
+	startTime, endTime := (&awsCloudwatchScaler{metadata: meta}).getQueryWindow(now)
+	if !endTime.Equal(now.Add(-60 * time.Second)) {
+		t.Errorf("Expected EndTime to be shifted by the offset but got %v", endTime)
+	}
+	if !startTime.Equal(endTime.Add(-defaultMetricCollectionTime * time.Second)) {
+		t.Errorf("Expected StartTime to be relative to EndTime but got %v", startTime)
+	}
+}
+
+func TestAWSCloudwatchQueryWindowClock(t *testing.T) {
+	meta := parseTestAWSCloudwatchMetadata(t, newTestAWSCloudwatchMetadata(map[string]string{"metricEndTimeOffset": "60"}))
+	now := time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)
+	client := &mockCloudwatch{output: &cloudwatch.GetMetricDataOutput{}}
+	scaler := awsCloudwatchScaler{metadata: meta, cwClients: []cloudwatchClient{client}, clock: func() time.Time { return now }}
+
+	if _, _, err := scaler.GetCloudwatchMetrics(context.Background()); err != nil {
+		t.Fatal("Could not get metrics:", err)
+	}
+	expectedEndTime := time.Date(2021, 10, 1, 11, 59, 0, 0, time.UTC)
+	expectedStartTime := time.Date(2021, 10, 1, 11, 54, 0, 0, time.UTC)
+	if !client.input.EndTime.Equal(expectedEndTime) || !client.input.StartTime.Equal(expectedStartTime) {
+		t.Errorf("Expected the window %v - %v but got %v - %v", expectedStartTime, expectedEndTime, *client.input.StartTime, *client.input.EndTime)
+	}
+}
+
+// recordingLogger keeps the messages logged at any verbosity
+type recordingLogger struct {
+	lines *[]string
+}
+
+func (l recordingLogger) Enabled() bool { return true }
+
+func (l recordingLogger) Info(msg string, keysAndValues ...interface{}) {
+	*l.lines = append(*l.lines, fmt.Sprint(msg, keysAndValues))
+}
+
+func (l recordingLogger) Error(err error, msg string, keysAndValues ...interface{}) {
+	*l.lines = append(*l.lines, fmt.Sprint(msg, err, keysAndValues))
+}
+
+func (l recordingLogger) V(level int) logr.Logger                             { return l }
+func (l recordingLogger) WithValues(keysAndValues ...interface{}) logr.Logger { return l }
+func (l recordingLogger) WithName(name string) logr.Logger                    { return l }
+
+func TestAWSCloudwatchLogsQuery(t *testing.T) {
+	var lines []string
+	defaultLog := cloudwatchLog
+	cloudwatchLog = recordingLogger{lines: &lines}
+	defer func() { cloudwatchLog = defaultLog }()
+
+	meta := parseTestAWSCloudwatchMetadata(t, newTestAWSCloudwatchMetadata(nil))
+	client := &mockCloudwatch{output: &cloudwatch.GetMetricDataOutput{
+		MetricDataResults: []*cloudwatch.MetricDataResult{{Values: []*float64{aws.Float64(1)}}},
+	}}
+	scaler := awsCloudwatchScaler{metadata: meta, cwClients: []cloudwatchClient{client}}
+
+	endTime := time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)
+	startTime := endTime.Add(-5 * time.Minute)
+	if _, _, err := scaler.GetCloudwatchMetricsForWindow(context.TODO(), startTime, endTime); err != nil {
+		t.Fatal("Could not get metrics:", err)
+	}
+
+	var query string
+	for _, line := range lines {
+		if strings.HasPrefix(line, "Querying cloudwatch metric") {
+			query = line
+		}
+	}
+	if query == "" {
+		t.Fatal("Expected the query to be logged")
+	}
+	for _, expected := range []string{"ApproximateNumberOfMessagesVisible", startTime.String(), endTime.String()} {
+		if !strings.Contains(query, expected) {
+			t.Errorf("Expected the query log to contain %s but got %s", expected, query)
+		}
+	}
+	for _, secret := range []string{meta.awsAuthorization.awsAccessKeyID, meta.awsAuthorization.awsSecretAccessKey} {
+		if secret != "" && strings.Contains(query, secret) {
+			t.Errorf("Expected the query log not to contain credentials but got %s", query)
+		}
+	}
+}
+
+func TestAWSCloudwatchAlarm(t *testing.T) {
+	metadata := map[string]string{
+		"alarmName":         "orders-backlog",
+		"targetMetricValue": "1",
+		"minMetricValue":    "0",
+		"awsRegion":         "eu-west-1",
+	}
+	meta, err := parseAwsCloudwatchMetadata(&ScalerConfig{TriggerMetadata: metadata, ResolvedEnv: testAWSCloudwatchResolvedEnv, AuthParams: testAWSAuthentication})
+	if err != nil {
+		t.Fatal("Could not parse metadata:", err)
+	}
+
+	tests := []struct {
+		output   *cloudwatch.DescribeAlarmsOutput
+		value    float64
+		isActive bool
+	}{
+		{&cloudwatch.DescribeAlarmsOutput{CompositeAlarms: []*cloudwatch.CompositeAlarm{{StateValue: aws.String(cloudwatch.StateValueAlarm)}}}, 1, true},
+		{&cloudwatch.DescribeAlarmsOutput{CompositeAlarms: []*cloudwatch.CompositeAlarm{{StateValue: aws.String(cloudwatch.StateValueOk)}}}, 0, false},
+		{&cloudwatch.DescribeAlarmsOutput{MetricAlarms: []*cloudwatch.MetricAlarm{{StateValue: aws.String(cloudwatch.StateValueAlarm)}}}, 1, true},
+		{&cloudwatch.DescribeAlarmsOutput{MetricAlarms: []*cloudwatch.MetricAlarm{{StateValue: aws.String(cloudwatch.StateValueInsufficientData)}}}, 0, false},
+	}
+	for _, test := range tests {
+		scaler := awsCloudwatchScaler{metadata: meta, cwClients: []cloudwatchClient{&mockCloudwatch{alarms: test.output}}}
+		isActive, err := scaler.IsActive(context.Background())
+		if err != nil {
+			t.Fatal("Could not get active state:", err)
+		}
+		if isActive != test.isActive {
+			t.Errorf("Expected isActive %v but got %v", test.isActive, isActive)
+		}
+		value, _, err := scaler.GetCloudwatchMetrics(context.Background())
+		if err != nil {
+			t.Fatal("Could not get metrics:", err)
+		}
+		if value != test.value {
+			t.Errorf("Expected value %v but got %v", test.value, value)
+		}
+	}
+
+	scaler := awsCloudwatchScaler{metadata: meta, cwClients: []cloudwatchClient{&mockCloudwatch{alarms: &cloudwatch.DescribeAlarmsOutput{}}}}
+	if _, _, err := scaler.GetCloudwatchMetrics(context.Background()); err == nil {
+		t.Error("Expected an error for a missing alarm")
+	}
+	if name := scaler.GetMetricSpecForScaling(context.Background())[0].External.Metric.Name; name != "s0-aws-cloudwatch-alarm-orders-backlog" {
+		t.Errorf("Expected the metric name to use the alarm name but got %s", name)
+	}
+
+	metadata["queries"] = `[{"id":"e1","expression":"SEARCH('{AWS/EC2} CPUUtilization', 'Average', 60)"}]`
+	if _, err := parseAwsCloudwatchMetadata(&ScalerConfig{TriggerMetadata: metadata, ResolvedEnv: testAWSCloudwatchResolvedEnv, AuthParams: testAWSAuthentication}); err == nil {
+		t.Error("Expected an error for alarmName with queries")
+	}
+}
+
+func TestAWSCloudwatchRatio(t *testing.T) {
+	metadata := newTestAWSCloudwatchMetadata(nil)
+	metadata["denominatorNamespace"] = "AWS/ECS"
+	metadata["denominatorMetricName"] = "RunningTaskCount"
+	metadata["denominatorDimensionName"] = "ServiceName"
+	metadata["denominatorDimensionValue"] = "consumer"
+
+	tests := []struct {
+		zeroDenominatorBehavior string
+		denominator             float64
+		value                   float64
+		isActive                bool
+	}{
+		{"", 4, 2.5, true},
+		{"", 0, 0, false},
+		{"zero", 0, 0, false},
+		{"numerator", 0, 10, true},
+	}
+	for _, test := range tests {
+		metadata["zeroDenominatorBehavior"] = test.zeroDenominatorBehavior
+		meta := parseTestAWSCloudwatchMetadata(t, metadata)
+		now := time.Now()
+		client := &mockCloudwatch{
+			output: &cloudwatch.GetMetricDataOutput{
+				MetricDataResults: []*cloudwatch.MetricDataResult{
+					{Id: aws.String("c1"), Values: []*float64{aws.Float64(10)}, Timestamps: []*time.Time{aws.Time(now)}},
+					{Id: aws.String("c2"), Values: []*float64{aws.Float64(test.denominator)}, Timestamps: []*time.Time{aws.Time(now)}},
+				},
+			},
+		}
+		scaler := awsCloudwatchScaler{metadata: meta, cwClients: []cloudwatchClient{client}}
+
+		isActive, err := scaler.IsActive(context.Background())
+		if err != nil {
+			t.Fatal("Could not get active state:", err)
+		}
+		if isActive != test.isActive {
+			t.Errorf("%s: Expected isActive %v for a denominator of %v but got %v", test.zeroDenominatorBehavior, test.isActive, test.denominator, isActive)
+		}
+		value, _, err := scaler.GetCloudwatchMetrics(context.Background())
+		if err != nil {
+			t.Fatal("Could not get metrics:", err)
+		}
+		if value != test.value {
+			t.Errorf("%s: Expected %v for a denominator of %v but got %v", test.zeroDenominatorBehavior, test.value, test.denominator, value)
+		}
+
+		queries := client.input.MetricDataQueries
+		if len(queries) != 2 || aws.StringValue(queries[1].Id) != "c2" ||
+			aws.StringValue(queries[1].MetricStat.Metric.Namespace) != "AWS/ECS" ||
+			aws.StringValue(queries[1].MetricStat.Metric.MetricName) != "RunningTaskCount" ||
+			aws.StringValue(queries[1].MetricStat.Metric.Dimensions[0].Value) != "consumer" {
+			t.Errorf("Expected the denominator query to be sent but got %v", queries)
+		}
+	}
+
+	metadata["zeroDenominatorBehavior"] = "infinity"
+	if _, err := parseAwsCloudwatchMetadata(&ScalerConfig{TriggerMetadata: metadata, ResolvedEnv: testAWSCloudwatchResolvedEnv, AuthParams: testAWSAuthentication}); err == nil {
+		t.Error("Expected an error for an invalid zeroDenominatorBehavior")
+	}
+	metadata["zeroDenominatorBehavior"] = ""
+	metadata["denominatorDimensionValue"] = "consumer;other"
+	if _, err := parseAwsCloudwatchMetadata(&ScalerConfig{TriggerMetadata: metadata, ResolvedEnv: testAWSCloudwatchResolvedEnv, AuthParams: testAWSAuthentication}); err == nil {
+		t.Error("Expected an error for mismatched denominator dimensions")
+	}
+}
+
+func TestAWSCloudwatchSharedQueryCache(t *testing.T) {
+	sharedCloudwatchQueryCache = &cloudwatchQueryCache{entries: map[string]*cloudwatchQueryCacheEntry{}}
+
+	metadata := newTestAWSCloudwatchMetadata(nil)
+	metadata["queryCacheTTL"] = "30s"
+	meta := parseTestAWSCloudwatchMetadata(t, metadata)
+	now := time.Now()
+	client := &mockCloudwatch{
+		output: &cloudwatch.GetMetricDataOutput{
+			MetricDataResults: []*cloudwatch.MetricDataResult{
+				{Id: aws.String("c1"), Values: []*float64{aws.Float64(10)}, Timestamps: []*time.Time{aws.Time(now)}},
+			},
+		},
+	}
+	first := &awsCloudwatchScaler{metadata: meta, cwClients: []cloudwatchClient{client}}
+	second := &awsCloudwatchScaler{metadata: meta, cwClients: []cloudwatchClient{client}}
+
+	startTime, endTime := now.Add(-5*time.Minute), now
+	for _, scaler := range []*awsCloudwatchScaler{first, second} {
+		value, _, err := scaler.GetCloudwatchMetricsForWindow(context.Background(), startTime, endTime)
+		if err != nil {
+			t.Fatal("Could not get metrics:", err)
+		}
+		if value != 10 {
+			t.Errorf("Expected value 10 but got %v", value)
+		}
+	}
+	if client.calls != 1 {
+		t.Errorf("Expected a single GetMetricData call but got %d", client.calls)
+	}
+
+	meta.queryCacheTTL = 0
+	if _, _, err := first.GetCloudwatchMetricsForWindow(context.Background(), startTime, endTime); err != nil {
+		t.Fatal("Could not get metrics:", err)
+	}
+	if client.calls != 2 {
+		t.Errorf("Expected the uncached query to call GetMetricData but got %d calls", client.calls)
+	}
+}
+
+func TestAWSCloudwatchQueryCacheFirstCallerCanceled(t *testing.T) {
+	cache := &cloudwatchQueryCache{entries: map[string]*cloudwatchQueryCacheEntry{}}
+	expected := &cloudwatch.GetMetricDataOutput{}
+	fetching := make(chan struct{})
+	var calls int32
+	fetch := func(ctx context.Context) (*cloudwatch.GetMetricDataOutput, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(fetching)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return expected, nil
+	}
+
+	firstCtx, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := cache.get(firstCtx, "key", time.Minute, fetch)
+		firstErr <- err
+	}()
+	<-fetching
+
+	type result struct {
+		output *cloudwatch.GetMetricDataOutput
+		err    error
+	}
+	second := make(chan result, 1)
+	go func() {
+		output, err := cache.get(context.Background(), "key", time.Minute, fetch)
+		second <- result{output, err}
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the first caller to be canceled but got %v", err)
+	}
+	r := <-second
+	if r.err != nil || r.output != expected {
+		t.Errorf("Expected the waiting caller to fetch again with its own context but got %v, %v", r.output, r.err)
+	}
+	output, err := cache.get(context.Background(), "key", time.Minute, fetch)
+	if err != nil || output != expected {
+		t.Errorf("Expected the output of the second fetch to be cached but got %v, %v", output, err)
+	}
+	if calls := atomic.LoadInt32(&calls); calls != 2 {
+		t.Errorf("Expected the canceled call and a single retry but got %d calls", calls)
+	}
+}
+
+func TestAWSCloudwatchInsightRule(t *testing.T) {
+	metadata := map[string]string{
+		"insightRuleName":   "api-clients",
+		"targetMetricValue": "10",
+		"minMetricValue":    "0",
+		"awsRegion":         "eu-west-1",
+	}
+	now := time.Now()
+	report := &cloudwatch.GetInsightRuleReportOutput{
+		MetricDatapoints: []*cloudwatch.InsightRuleMetricDatapoint{
+			{Timestamp: aws.Time(now.Add(-time.Minute)), UniqueContributors: aws.Float64(12), Maximum: aws.Float64(40)},
+			{Timestamp: aws.Time(now), UniqueContributors: aws.Float64(7), Maximum: aws.Float64(30)},
+		},
+	}
+
+	tests := []struct {
+		insightRuleMetric string
+		value             float64
+	}{
+		{"", 7},
+		{"Maximum", 30},
+	}
+	for _, test := range tests {
+		metadata["insightRuleMetric"] = test.insightRuleMetric
+		meta, err := parseAwsCloudwatchMetadata(&ScalerConfig{TriggerMetadata: metadata, ResolvedEnv: testAWSCloudwatchResolvedEnv, AuthParams: testAWSAuthentication})
+		if err != nil {
+			t.Fatal("Could not parse metadata:", err)
+		}
+		scaler := awsCloudwatchScaler{metadata: meta, cwClients: []cloudwatchClient{&mockCloudwatch{report: report}}}
+		value, timestamp, err := scaler.GetCloudwatchMetrics(context.Background())
+		if err != nil {
+			t.Fatal("Could not get metrics:", err)
+		}
+		if value != test.value || !timestamp.Equal(now) {
+			t.Errorf("Expected value %v at %v but got %v at %v", test.value, now, value, timestamp)
+		}
+	}
+
+	metadata["insightRuleMetric"] = ""
+	meta, err := parseAwsCloudwatchMetadata(&ScalerConfig{TriggerMetadata: metadata, ResolvedEnv: testAWSCloudwatchResolvedEnv, AuthParams: testAWSAuthentication})
+	if err != nil {
+		t.Fatal("Could not parse metadata:", err)
+	}
+	scaler := awsCloudwatchScaler{metadata: meta, cwClients: []cloudwatchClient{&mockCloudwatch{report: &cloudwatch.GetInsightRuleReportOutput{}}}}
+	if value, _, err := scaler.GetCloudwatchMetrics(context.Background()); err != nil || value != 0 {
+		t.Errorf("Expected minMetricValue without datapoints but got %v, %v", value, err)
+	}
+	if name := scaler.GetMetricSpecForScaling(context.Background())[0].External.Metric.Name; name != "s0-aws-cloudwatch-insight-rule-api-clients" {
+		t.Errorf("Expected the metric name to use the rule name but got %s", name)
+	}
+
+	invalid := []map[string]string{
+		{"insightRuleName": "api-clients", "insightRuleMetric": "Unknown"},
+		{"insightRuleMetric": "UniqueContributors"},
+		{"insightRuleName": "api-clients", "alarmName": "orders-backlog"},
+	}
+	for _, extra := range invalid {
+		metadata := map[string]string{"targetMetricValue": "10", "minMetricValue": "0", "awsRegion": "eu-west-1"}
+		for k, v := range extra {
+			metadata[k] = v
+		}
+		if _, err := parseAwsCloudwatchMetadata(&ScalerConfig{TriggerMetadata: metadata, ResolvedEnv: testAWSCloudwatchResolvedEnv, AuthParams: testAWSAuthentication}); err == nil {
+			t.Errorf("Expected an error for %v", extra)
+		}
+	}
+}
+
+func TestAWSCloudwatchMultipleStats(t *testing.T) {
+	metadata := newTestAWSCloudwatchMetadata(nil)
+	metadata["metricStat"] = "Average, p99"
+	metadata["metricStatPeriod"] = "60"
+
+	now := time.Now()
+	output := &cloudwatch.GetMetricDataOutput{
+		MetricDataResults: []*cloudwatch.MetricDataResult{
+			{Id: aws.String("c1"), Values: []*float64{aws.Float64(4)}, Timestamps: []*time.Time{aws.Time(now)}},
+			{Id: aws.String("c2"), Values: []*float64{aws.Float64(10)}, Timestamps: []*time.Time{aws.Time(now)}},
+		},
+	}
+
+	tests := []struct {
+		metricStatAggregation string
+		value                 float64
+	}{
+		{"", 10},
+		{"min", 4},
+		{"avg", 7},
+	}
+	for _, test := range tests {
+		metadata["metricStatAggregation"] = test.metricStatAggregation
+		meta := parseTestAWSCloudwatchMetadata(t, metadata)
+		client := &mockCloudwatch{output: output}
+		scaler := awsCloudwatchScaler{metadata: meta, cwClients: []cloudwatchClient{client}}
+		value, _, err := scaler.GetCloudwatchMetrics(context.Background())
+		if err != nil {
+			t.Fatal("Could not get metrics:", err)
+		}
+		if value != test.value {
+			t.Errorf("Expected value %v for metricStatAggregation %q but got %v", test.value, test.metricStatAggregation, value)
+		}
+
+		queries := client.input.MetricDataQueries
+		if len(queries) != 2 || *queries[0].MetricStat.Stat != "Average" || *queries[1].MetricStat.Stat != "p99" {
+			t.Errorf("Expected an Average and a p99 query but got %v", queries)
+		}
+		if *queries[0].MetricStat.Metric.MetricName != *queries[1].MetricStat.Metric.MetricName {
+			t.Error("Expected the queries to share the metric")
+		}
+	}
+
+	invalid := []map[string]string{
+		{"metricStat": "Average,Avg"},
+		{"metricStat": "Average,p99", "metricStatPeriod": "30"},
+		{"metricStat": "Average,Maximum", "metricStatAggregation": "sum"},
+		{"metricStat": "Average,Maximum", "expression": "SELECT AVG(CPUUtilization) FROM SCHEMA(\"AWS/EC2\")"},
+	}
+	for _, extra := range invalid {
+		metadata := newTestAWSCloudwatchMetadata(nil)
+		for k, v := range extra {
+			metadata[k] = v
+		}
+		if _, err := parseAwsCloudwatchMetadata(&ScalerConfig{TriggerMetadata: metadata, ResolvedEnv: testAWSCloudwatchResolvedEnv, AuthParams: testAWSAuthentication}); err == nil {
+			t.Errorf("Expected an error for %v", extra)
+		}
+	}
+}
+
+func TestAWSCloudwatchMinSampleCount(t *testing.T) {
+	metadata := newTestAWSCloudwatchMetadata(nil)
+	metadata["minSampleCount"] = "10"
+	meta := parseTestAWSCloudwatchMetadata(t, metadata)
+
+	tests := []struct {
+		sampleCount float64
+		value       float64
+		isActive    bool
+	}{
+		{3, 0, false},
+		{10, 5, true},
+	}
+	for _, test := range tests {
+		now := time.Now()
+		client := &mockCloudwatch{
+			output: &cloudwatch.GetMetricDataOutput{
+				MetricDataResults: []*cloudwatch.MetricDataResult{
+					{Id: aws.String("c1"), Values: []*float64{aws.Float64(5)}, Timestamps: []*time.Time{aws.Time(now)}},
+					{Id: aws.String("sc"), Values: []*float64{aws.Float64(test.sampleCount)}, Timestamps: []*time.Time{aws.Time(now)}},
+				},
+			},
+		}
+		scaler := awsCloudwatchScaler{metadata: meta, cwClients: []cloudwatchClient{client}}
+
+		isActive, err := scaler.IsActive(context.Background())
+		if err != nil {
+			t.Fatal("Could not get active state:", err)
+		}
+		if isActive != test.isActive {
+			t.Errorf("Expected isActive %v with %v samples but got %v", test.isActive, test.sampleCount, isActive)
+		}
+		value, _, err := scaler.GetCloudwatchMetrics(context.Background())
+		if err != nil {
+			t.Fatal("Could not get metrics:", err)
+		}
+		if value != test.value {
+			t.Errorf("Expected value %v with %v samples but got %v", test.value, test.sampleCount, value)
+		}
+
+		queries := client.input.MetricDataQueries
+		if len(queries) != 2 || *queries[1].Id != "sc" || *queries[1].MetricStat.Stat != "SampleCount" {
+			t.Errorf("Expected a SampleCount query next to the metric but got %v", queries)
+		}
+	}
+
+	metadata["minSampleCount"] = "-1"
+	if _, err := parseAwsCloudwatchMetadata(&ScalerConfig{TriggerMetadata: metadata, ResolvedEnv: testAWSCloudwatchResolvedEnv, AuthParams: testAWSAuthentication}); err == nil {
+		t.Error("Expected an error for a negative minSampleCount")
+	}
+}
+
+func TestAWSCloudwatchAccountID(t *testing.T) {
+	now := time.Now()
+	client := &mockCloudwatch{
+		output: &cloudwatch.GetMetricDataOutput{
+			MetricDataResults: []*cloudwatch.MetricDataResult{
+				{Id: aws.String("c1"), Values: []*float64{aws.Float64(5)}, Timestamps: []*time.Time{aws.Time(now)}},
+			},
+		},
+	}
+	meta := parseTestAWSCloudwatchMetadata(t, newTestAWSCloudwatchMetadata(nil))
+	scaler := awsCloudwatchScaler{metadata: meta, cwClients: []cloudwatchClient{client}}
+	if _, _, err := scaler.GetCloudwatchMetrics(context.Background()); err != nil {
+		t.Fatal("Could not get metrics:", err)
+	}
+	if client.input.MetricDataQueries[0].AccountId != nil {
+		t.Errorf("Expected no AccountId by default but got %s", *client.input.MetricDataQueries[0].AccountId)
+	}
+
+	meta = parseTestAWSCloudwatchMetadata(t, newTestAWSCloudwatchMetadata(map[string]string{"awsAccountId": "123456789012"}))
+	scaler = awsCloudwatchScaler{metadata: meta, cwClients: []cloudwatchClient{client}}
+	if _, _, err := scaler.GetCloudwatchMetrics(context.Background()); err != nil {
+		t.Fatal("Could not get metrics:", err)
+	}
+	query := client.input.MetricDataQueries[0]
+	if query.AccountId == nil || *query.AccountId != "123456789012" {
+		t.Errorf("Expected the AccountId 123456789012 on the query but got %v", query.AccountId)
+	}
+}
@@ -3,7 +3,8 @@ package scalers
 import "fmt"
 
 type awsAuthorizationMetadata struct {
-	awsRoleArn string
+	awsRoleArn        string
+	awsRoleExternalID string
 
 	awsAccessKeyID     string
 	awsSecretAccessKey string
@@ -21,6 +22,7 @@ func getAwsAuthorization(authParams, metadata, resolvedEnv map[string]string) (a
 		switch {
 		case authParams["awsRoleArn"] != "":
 			meta.awsRoleArn = authParams["awsRoleArn"]
+			meta.awsRoleExternalID = authParams["awsRoleExternalId"]
 		case (authParams["awsAccessKeyID"] != "" || authParams["awsAccessKeyId"] != "") && authParams["awsSecretAccessKey"] != "":
 			meta.awsAccessKeyID = authParams["awsAccessKeyID"]
 			if meta.awsAccessKeyID == "" {
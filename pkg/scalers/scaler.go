@@ -101,6 +101,12 @@ func GetFromAuthOrMeta(config *ScalerConfig, field string) (string, error) {
 	return result, err
 }
 
+// ScalerFailedMessage prefixes the error with the index and type of the failing scaler,
+// so the KEDAScalerFailed events can be mapped to their trigger
+func ScalerFailedMessage(scalerIndex int, scaler Scaler, err error) string {
+	return fmt.Sprintf("trigger #%d (%T): %s", scalerIndex, scaler, err)
+}
+
 // GenerateMetricNameWithIndex helps adding the index prefix to the metric name
 func GenerateMetricNameWithIndex(scalerIndex int, metricName string) string {
 	return fmt.Sprintf("s%d-%s", scalerIndex, metricName)
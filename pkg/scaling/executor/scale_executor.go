@@ -39,7 +39,7 @@ const (
 // ScaleExecutor contains methods RequestJobScale and RequestScale
 type ScaleExecutor interface {
 	RequestJobScale(ctx context.Context, scaledJob *kedav1alpha1.ScaledJob, isActive bool, scaleTo int64, maxScale int64)
-	RequestScale(ctx context.Context, scaledObject *kedav1alpha1.ScaledObject, isActive bool, isError bool)
+	RequestScale(ctx context.Context, scaledObject *kedav1alpha1.ScaledObject, isActive bool, scalerErrors map[int]error)
 }
 
 type scaleExecutor struct {
@@ -117,6 +117,13 @@ func (e *scaleExecutor) setActiveCondition(ctx context.Context, logger logr.Logg
 	return e.setCondition(ctx, logger, object, status, reason, message, active)
 }
 
+func (e *scaleExecutor) setReadyCondition(ctx context.Context, logger logr.Logger, object interface{}, status metav1.ConditionStatus, reason string, message string) error {
+	ready := func(conditions kedav1alpha1.Conditions, status metav1.ConditionStatus, reason string, message string) {
+		conditions.SetReadyCondition(status, reason, message)
+	}
+	return e.setCondition(ctx, logger, object, status, reason, message, ready)
+}
+
 func (e *scaleExecutor) setFallbackCondition(ctx context.Context, logger logr.Logger, object interface{}, status metav1.ConditionStatus, reason string, message string) error {
 	fallback := func(conditions kedav1alpha1.Conditions, status metav1.ConditionStatus, reason string, message string) {
 		conditions.SetFallbackCondition(status, reason, message)
@@ -18,6 +18,9 @@ package executor
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -31,7 +34,14 @@ import (
 	"github.com/kedacore/keda/v2/pkg/eventreason"
 )
 
-func (e *scaleExecutor) RequestScale(ctx context.Context, scaledObject *kedav1alpha1.ScaledObject, isActive bool, isError bool) {
+// scalerFailedReason is the reason of a Ready condition set from the scaler errors,
+// so it is reset once the scalers recover but not when the ScaledObject check failed
+const scalerFailedReason = "ScalerFailed"
+
+// RequestScale scales the target of the ScaledObject and reports the scalerErrors, keyed
+// by the trigger index, on its Ready condition. The push scalers only report the activity
+// and pass nil scalerErrors, which leaves the Ready condition to the polling loop
+func (e *scaleExecutor) RequestScale(ctx context.Context, scaledObject *kedav1alpha1.ScaledObject, isActive bool, scalerErrors map[int]error) {
 	logger := e.logger.WithValues("scaledobject.Name", scaledObject.Name,
 		"scaledObject.Namespace", scaledObject.Namespace,
 		"scaleTarget.Name", scaledObject.Spec.ScaleTargetRef.Name)
@@ -69,6 +79,11 @@ func (e *scaleExecutor) RequestScale(ctx context.Context, scaledObject *kedav1al
 		currentReplicas = currentScale.Spec.Replicas
 	}
 
+	if err := e.updateReadyConditionFromScalerErrors(ctx, logger, scaledObject, scalerErrors); err != nil {
+		logger.Error(err, "Error setting ready condition from the scaler errors")
+	}
+	isError := len(scalerErrors) > 0
+
 	// if scaledObject.Spec.MinReplicaCount is not set, then set the default value (0)
 	minReplicas := int32(0)
 	if scaledObject.Spec.MinReplicaCount != nil {
@@ -286,3 +301,42 @@ func getIdleOrMinimumReplicaCount(scaledObject *kedav1alpha1.ScaledObject) (bool
 
 	return false, *scaledObject.Spec.MinReplicaCount
 }
+
+// updateReadyConditionFromScalerErrors sets the Ready condition to Unknown with the errors of the failing
+// scalers, a False condition from the ScaledObject check is left as is since it takes precedence
+func (e *scaleExecutor) updateReadyConditionFromScalerErrors(ctx context.Context, logger logr.Logger, scaledObject *kedav1alpha1.ScaledObject, scalerErrors map[int]error) error {
+	if scalerErrors == nil {
+		return nil
+	}
+	condition := scaledObject.Status.Conditions.GetReadyCondition()
+	if len(scalerErrors) == 0 {
+		if condition.Reason != scalerFailedReason {
+			return nil
+		}
+		return e.setReadyCondition(ctx, logger, scaledObject, metav1.ConditionTrue, "ScaledObjectReady", "ScaledObject is defined correctly and is ready for scaling")
+	}
+	if condition.IsFalse() {
+		return nil
+	}
+
+	message := scalerErrorsMessage(scalerErrors)
+	if condition.Reason == scalerFailedReason && condition.Message == message {
+		return nil
+	}
+	return e.setReadyCondition(ctx, logger, scaledObject, metav1.ConditionUnknown, scalerFailedReason, message)
+}
+
+// scalerErrorsMessage lists the scaler errors ordered by the trigger index
+func scalerErrorsMessage(scalerErrors map[int]error) string {
+	indexes := make([]int, 0, len(scalerErrors))
+	for i := range scalerErrors {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
+	messages := make([]string, 0, len(indexes))
+	for _, i := range indexes {
+		messages = append(messages, fmt.Sprintf("trigger #%d: %s", i, scalerErrors[i]))
+	}
+	return strings.Join(messages, "; ")
+}
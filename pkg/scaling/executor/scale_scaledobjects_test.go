@@ -18,6 +18,7 @@ package executor
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/golang/mock/gomock"
@@ -84,14 +85,18 @@ func TestScaleToFallbackReplicasWhenNotActiveAndIsError(t *testing.T) {
 	mockScaleInterface.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(scale, nil)
 	mockScaleInterface.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Eq(scale), gomock.Any())
 
-	client.EXPECT().Status().Times(2).Return(statusWriter)
-	statusWriter.EXPECT().Patch(gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+	client.EXPECT().Status().Times(3).Return(statusWriter)
+	statusWriter.EXPECT().Patch(gomock.Any(), gomock.Any(), gomock.Any()).Times(3)
 
-	scaleExecutor.RequestScale(context.TODO(), &scaledObject, false, true)
+	scaleExecutor.RequestScale(context.TODO(), &scaledObject, false, map[int]error{1: errors.New("connection refused"), 0: errors.New("timeout")})
 
 	assert.Equal(t, int32(5), scale.Spec.Replicas)
 	condition := scaledObject.Status.Conditions.GetFallbackCondition()
 	assert.Equal(t, true, condition.IsTrue())
+	readyCondition := scaledObject.Status.Conditions.GetReadyCondition()
+	assert.Equal(t, true, readyCondition.IsUnknown())
+	assert.Equal(t, "ScalerFailed", readyCondition.Reason)
+	assert.Equal(t, "trigger #0: timeout; trigger #1: connection refused", readyCondition.Message)
 }
 
 func TestScaleToMinReplicasWhenNotActive(t *testing.T) {
@@ -148,7 +153,7 @@ func TestScaleToMinReplicasWhenNotActive(t *testing.T) {
 	client.EXPECT().Status().Return(statusWriter)
 	statusWriter.EXPECT().Patch(gomock.Any(), gomock.Any(), gomock.Any())
 
-	scaleExecutor.RequestScale(context.TODO(), &scaledObject, false, false)
+	scaleExecutor.RequestScale(context.TODO(), &scaledObject, false, nil)
 
 	assert.Equal(t, minReplicas, scale.Spec.Replicas)
 	condition := scaledObject.Status.Conditions.GetActiveCondition()
@@ -209,7 +214,7 @@ func TestScaleToMinReplicasFromLowerInitialReplicaCount(t *testing.T) {
 	client.EXPECT().Status().Return(statusWriter)
 	statusWriter.EXPECT().Patch(gomock.Any(), gomock.Any(), gomock.Any())
 
-	scaleExecutor.RequestScale(context.TODO(), &scaledObject, false, false)
+	scaleExecutor.RequestScale(context.TODO(), &scaledObject, false, nil)
 
 	assert.Equal(t, minReplicas, scale.Spec.Replicas)
 	condition := scaledObject.Status.Conditions.GetActiveCondition()
@@ -268,7 +273,7 @@ func TestScaleFromMinReplicasWhenActive(t *testing.T) {
 	client.EXPECT().Status().Times(2).Return(statusWriter)
 	statusWriter.EXPECT().Patch(gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
 
-	scaleExecutor.RequestScale(context.TODO(), &scaledObject, true, false)
+	scaleExecutor.RequestScale(context.TODO(), &scaledObject, true, nil)
 
 	assert.Equal(t, int32(1), scale.Spec.Replicas)
 	condition := scaledObject.Status.Conditions.GetActiveCondition()
@@ -331,7 +336,7 @@ func TestScaleToIdleReplicasWhenNotActive(t *testing.T) {
 	client.EXPECT().Status().Return(statusWriter)
 	statusWriter.EXPECT().Patch(gomock.Any(), gomock.Any(), gomock.Any())
 
-	scaleExecutor.RequestScale(context.TODO(), &scaledObject, false, false)
+	scaleExecutor.RequestScale(context.TODO(), &scaledObject, false, nil)
 
 	assert.Equal(t, idleReplicas, scale.Spec.Replicas)
 	condition := scaledObject.Status.Conditions.GetActiveCondition()
@@ -392,9 +397,105 @@ func TestScaleFromIdleToMinReplicasWhenActive(t *testing.T) {
 	client.EXPECT().Status().Times(2).Return(statusWriter)
 	statusWriter.EXPECT().Patch(gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
 
-	scaleExecutor.RequestScale(context.TODO(), &scaledObject, true, false)
+	scaleExecutor.RequestScale(context.TODO(), &scaledObject, true, nil)
 
 	assert.Equal(t, minReplicas, scale.Spec.Replicas)
 	condition := scaledObject.Status.Conditions.GetActiveCondition()
 	assert.Equal(t, true, condition.IsTrue())
 }
+
+func TestUpdateReadyConditionFromScalerErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		ready        v1alpha1.Condition
+		scalerErrors map[int]error
+		patched      bool
+		status       v1.ConditionStatus
+		reason       string
+		message      string
+	}{
+		{"scalers recovered", v1alpha1.Condition{Status: v1.ConditionUnknown, Reason: "ScalerFailed", Message: "trigger #0: timeout"}, map[int]error{}, true, v1.ConditionTrue, "ScaledObjectReady", "ScaledObject is defined correctly and is ready for scaling"},
+		{"push scaler", v1alpha1.Condition{Status: v1.ConditionUnknown, Reason: "ScalerFailed", Message: "trigger #0: timeout"}, nil, false, v1.ConditionUnknown, "ScalerFailed", "trigger #0: timeout"},
+		{"ready without errors", v1alpha1.Condition{Status: v1.ConditionTrue, Reason: "ScaledObjectReady"}, nil, false, v1.ConditionTrue, "ScaledObjectReady", ""},
+		{"same errors", v1alpha1.Condition{Status: v1.ConditionUnknown, Reason: "ScalerFailed", Message: "trigger #0: timeout"}, map[int]error{0: errors.New("timeout")}, false, v1.ConditionUnknown, "ScalerFailed", "trigger #0: timeout"},
+		{"check failed", v1alpha1.Condition{Status: v1.ConditionFalse, Reason: "ScaledObjectCheckFailed"}, map[int]error{0: errors.New("timeout")}, false, v1.ConditionFalse, "ScaledObjectCheckFailed", ""},
+	}
+	for _, test := range tests {
+		ctrl := gomock.NewController(t)
+		client := mock_client.NewMockClient(ctrl)
+		statusWriter := mock_client.NewMockStatusWriter(ctrl)
+		scaleExecutor := NewScaleExecutor(client, nil, nil, record.NewFakeRecorder(1)).(*scaleExecutor)
+
+		scaledObject := v1alpha1.ScaledObject{}
+		scaledObject.Status.Conditions = *v1alpha1.GetInitializedConditions()
+		scaledObject.Status.Conditions.SetReadyCondition(test.ready.Status, test.ready.Reason, test.ready.Message)
+		if test.patched {
+			client.EXPECT().Status().Return(statusWriter)
+			statusWriter.EXPECT().Patch(gomock.Any(), gomock.Any(), gomock.Any())
+		}
+
+		err := scaleExecutor.updateReadyConditionFromScalerErrors(context.TODO(), scaleExecutor.logger, &scaledObject, test.scalerErrors)
+
+		assert.NoError(t, err, test.name)
+		condition := scaledObject.Status.Conditions.GetReadyCondition()
+		assert.Equal(t, test.status, condition.Status, test.name)
+		assert.Equal(t, test.reason, condition.Reason, test.name)
+		assert.Equal(t, test.message, condition.Message, test.name)
+		ctrl.Finish()
+	}
+}
+
+func TestPushScalerKeepsReadyConditionUntilPolling(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := mock_client.NewMockClient(ctrl)
+	recorder := record.NewFakeRecorder(1)
+	statusWriter := mock_client.NewMockStatusWriter(ctrl)
+
+	scaleExecutor := NewScaleExecutor(client, nil, nil, recorder)
+
+	minReplicas := int32(0)
+	replicas := int32(2)
+
+	scaledObject := v1alpha1.ScaledObject{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "name",
+			Namespace: "namespace",
+		},
+		Spec: v1alpha1.ScaledObjectSpec{
+			ScaleTargetRef: &v1alpha1.ScaleTarget{
+				Name: "name",
+			},
+			MinReplicaCount: &minReplicas,
+		},
+		Status: v1alpha1.ScaledObjectStatus{
+			ScaleTargetGVKR: &v1alpha1.GroupVersionKindResource{
+				Group: "apps",
+				Kind:  "Deployment",
+			},
+		},
+	}
+
+	scaledObject.Status.Conditions = *v1alpha1.GetInitializedConditions()
+	scaledObject.Status.Conditions.SetReadyCondition(v1.ConditionUnknown, "ScalerFailed", "trigger #0: timeout")
+
+	client.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any()).SetArg(2, appsv1.Deployment{
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+		},
+	}).Times(2)
+	client.EXPECT().Status().AnyTimes().Return(statusWriter)
+	statusWriter.EXPECT().Patch(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+
+	// the push scaler doesn't know about the errors of the other triggers
+	scaleExecutor.RequestScale(context.TODO(), &scaledObject, true, nil)
+
+	condition := scaledObject.Status.Conditions.GetReadyCondition()
+	assert.Equal(t, v1.ConditionUnknown, condition.Status)
+	assert.Equal(t, "ScalerFailed", condition.Reason)
+
+	scaleExecutor.RequestScale(context.TODO(), &scaledObject, true, map[int]error{})
+
+	condition = scaledObject.Status.Conditions.GetReadyCondition()
+	assert.Equal(t, v1.ConditionTrue, condition.Status)
+	assert.Equal(t, "ScaledObjectReady", condition.Reason)
+}
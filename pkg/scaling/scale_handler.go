@@ -19,6 +19,7 @@ package scaling
 import (
 	"context"
 	"fmt"
+	"os"
 	"sync"
 	"time"
 
@@ -47,6 +48,10 @@ type ScaleHandler interface {
 	GetScalers(ctx context.Context, scalableObject interface{}) ([]scalers.Scaler, error)
 }
 
+// defaultScalerTimeout is how long a scaler may take to report whether it is active
+// or to return its metrics
+const defaultScalerTimeout = 15 * time.Second
+
 type scaleHandler struct {
 	client            client.Client
 	logger            logr.Logger
@@ -54,20 +59,40 @@ type scaleHandler struct {
 	scaleExecutor     executor.ScaleExecutor
 	globalHTTPTimeout time.Duration
 	recorder          record.EventRecorder
+
+	// scalerTimeout bounds each IsActive call so a hung scaler doesn't block the
+	// scale decision, it is disabled when 0
+	scalerTimeout time.Duration
 }
 
 // NewScaleHandler creates a ScaleHandler object
 func NewScaleHandler(client client.Client, scaleClient scale.ScalesGetter, reconcilerScheme *runtime.Scheme, globalHTTPTimeout time.Duration, recorder record.EventRecorder) ScaleHandler {
+	logger := logf.Log.WithName("scalehandler")
 	return &scaleHandler{
 		client:            client,
-		logger:            logf.Log.WithName("scalehandler"),
+		logger:            logger,
 		scaleLoopContexts: &sync.Map{},
 		scaleExecutor:     executor.NewScaleExecutor(client, scaleClient, reconcilerScheme, recorder),
 		globalHTTPTimeout: globalHTTPTimeout,
 		recorder:          recorder,
+		scalerTimeout:     GetScalerTimeout(logger),
 	}
 }
 
+// GetScalerTimeout reads KEDA_SCALER_TIMEOUT as a duration like 45s, 0 disables the timeout
+func GetScalerTimeout(logger logr.Logger) time.Duration {
+	val := os.Getenv("KEDA_SCALER_TIMEOUT")
+	if val == "" {
+		return defaultScalerTimeout
+	}
+	timeout, err := time.ParseDuration(val)
+	if err != nil || timeout < 0 {
+		logger.Error(err, "Invalid KEDA_SCALER_TIMEOUT, using the default", "value", val, "default", defaultScalerTimeout)
+		return defaultScalerTimeout
+	}
+	return timeout
+}
+
 func (h *scaleHandler) GetScalers(ctx context.Context, scalableObject interface{}) ([]scalers.Scaler, error) {
 	withTriggers, err := asDuckWithTriggers(scalableObject)
 	if err != nil {
@@ -194,7 +219,8 @@ func (h *scaleHandler) startPushScalers(ctx context.Context, withTriggers *kedav
 					scalingMutex.Lock()
 					switch obj := scalableObject.(type) {
 					case *kedav1alpha1.ScaledObject:
-						h.scaleExecutor.RequestScale(ctx, obj, active, false)
+						// nil scalerErrors leave the Ready condition to checkScalers
+						h.scaleExecutor.RequestScale(ctx, obj, active, nil)
 					case *kedav1alpha1.ScaledJob:
 						h.logger.Info("Warning: External Push Scaler does not support ScaledJob", "object", scalableObject)
 					}
@@ -223,30 +249,38 @@ func (h *scaleHandler) checkScalers(ctx context.Context, scalableObject interfac
 			h.logger.Error(err, "Error getting scaledObject", "object", scalableObject)
 			return
 		}
-		isActive, isError := h.isScaledObjectActive(ctx, scalers, obj)
-		h.scaleExecutor.RequestScale(ctx, obj, isActive, isError)
+		isActive, scalerErrors := h.isScaledObjectActiveWithErrors(ctx, scalers, obj)
+		h.scaleExecutor.RequestScale(ctx, obj, isActive, scalerErrors)
 	case *kedav1alpha1.ScaledJob:
 		err = h.client.Get(ctx, types.NamespacedName{Name: obj.Name, Namespace: obj.Namespace}, obj)
 		if err != nil {
 			h.logger.Error(err, "Error getting scaledJob", "object", scalableObject)
 			return
 		}
-		isActive, scaleTo, maxScale := h.isScaledJobActive(ctx, scalers, obj)
-		h.scaleExecutor.RequestJobScale(ctx, obj, isActive, scaleTo, maxScale)
+		result := h.isScaledJobActive(ctx, scalers, obj)
+		h.scaleExecutor.RequestJobScale(ctx, obj, result.IsActive, result.QueueLength, result.MaxValue)
 	}
 }
 
 func (h *scaleHandler) isScaledObjectActive(ctx context.Context, scalers []scalers.Scaler, scaledObject *kedav1alpha1.ScaledObject) (bool, bool) {
+	isActive, scalerErrors := h.isScaledObjectActiveWithErrors(ctx, scalers, scaledObject)
+	return isActive, len(scalerErrors) > 0
+}
+
+// isScaledObjectActiveWithErrors returns the errors of the failing scalers keyed by the scaler index
+func (h *scaleHandler) isScaledObjectActiveWithErrors(ctx context.Context, scalerList []scalers.Scaler, scaledObject *kedav1alpha1.ScaledObject) (bool, map[int]error) {
 	isActive := false
-	isError := false
-	for i, scaler := range scalers {
-		isTriggerActive, err := scaler.IsActive(ctx)
-		scaler.Close(ctx)
+	scalerErrors := map[int]error{}
+	// by default the first active trigger is enough for the scale decision,
+	// evaluateAllTriggers checks the remaining ones too to report their activity
+	evaluateAll := scaledObject.Spec.Advanced != nil && scaledObject.Spec.Advanced.EvaluateAllTriggers
+	for i, scaler := range scalerList {
+		isTriggerActive, err := h.isScalerActiveAndClose(ctx, scaler)
 
 		if err != nil {
 			h.logger.V(1).Info("Error getting scale decision", "Error", err)
-			isError = true
-			h.recorder.Event(scaledObject, corev1.EventTypeWarning, eventreason.KEDAScalerFailed, err.Error())
+			scalerErrors[i] = err
+			h.recorder.Event(scaledObject, corev1.EventTypeWarning, eventreason.KEDAScalerFailed, scalers.ScalerFailedMessage(i, scaler, err))
 			continue
 		} else if isTriggerActive {
 			isActive = true
@@ -256,15 +290,54 @@ func (h *scaleHandler) isScaledObjectActive(ctx context.Context, scalers []scale
 			if resourceMetricsSpec := scaler.GetMetricSpecForScaling(ctx)[0].Resource; resourceMetricsSpec != nil {
 				h.logger.V(1).Info("Scaler for scaledObject is active", "Metrics Name", resourceMetricsSpec.Name)
 			}
-			closeScalers(ctx, scalers[i+1:])
-			break
+			if !evaluateAll {
+				h.closeScalers(ctx, scalerList[i+1:])
+				break
+			}
+		} else if evaluateAll {
+			h.logger.V(1).Info("Scaler for scaledObject is not active", "Scaler", fmt.Sprintf("%T", scaler))
+		}
+	}
+	return isActive, scalerErrors
+}
+
+// isScalerActiveAndClose closes the scaler once IsActive returns. It returns an error when
+// the scaler doesn't answer within scalerTimeout, even if it ignores the cancellation of its
+// context, and the scaler is then closed in the background when IsActive eventually returns
+func (h *scaleHandler) isScalerActiveAndClose(ctx context.Context, scaler scalers.Scaler) (bool, error) {
+	if h.scalerTimeout <= 0 {
+		isActive, err := scaler.IsActive(ctx)
+		scaler.Close(ctx)
+		return isActive, err
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, h.scalerTimeout)
+	defer cancel()
+
+	type result struct {
+		isActive bool
+		err      error
+	}
+	done := make(chan result, 1)
+	go func() {
+		isActive, err := scaler.IsActive(timeoutCtx)
+		scaler.Close(ctx)
+		done <- result{isActive, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.isActive, r.err
+	case <-timeoutCtx.Done():
+		if err := ctx.Err(); err != nil {
+			return false, fmt.Errorf("scale decision was interrupted: %w", err)
 		}
+		return false, fmt.Errorf("scaler did not respond within %s: %w", h.scalerTimeout, timeoutCtx.Err())
 	}
-	return isActive, isError
 }
 
-func (h *scaleHandler) isScaledJobActive(ctx context.Context, scalers []scalers.Scaler, scaledJob *kedav1alpha1.ScaledJob) (bool, int64, int64) {
-	return scaledjob.GetScaleMetrics(ctx, scalers, scaledJob, h.recorder)
+func (h *scaleHandler) isScaledJobActive(ctx context.Context, scalers []scalers.Scaler, scaledJob *kedav1alpha1.ScaledJob) scaledjob.ScaledJobResult {
+	return scaledjob.GetScaledJobResult(ctx, scalers, scaledJob, h.recorder)
 }
 
 // buildScalers returns list of Scalers for the specified triggers
@@ -281,6 +354,12 @@ func (h *scaleHandler) buildScalers(ctx context.Context, withTriggers *kedav1alp
 	}
 
 	for scalerIndex, trigger := range withTriggers.Spec.Triggers {
+		// don't build the remaining scalers if the scale loop is being torn down
+		if err := ctx.Err(); err != nil {
+			h.closeScalers(ctx, scalersRes)
+			return []scalers.Scaler{}, err
+		}
+
 		config := &scalers.ScalerConfig{
 			Name:              withTriggers.Name,
 			Namespace:         withTriggers.Namespace,
@@ -293,13 +372,13 @@ func (h *scaleHandler) buildScalers(ctx context.Context, withTriggers *kedav1alp
 
 		config.AuthParams, config.PodIdentity, err = resolver.ResolveAuthRefAndPodIdentity(h.client, logger, trigger.AuthenticationRef, podTemplateSpec, withTriggers.Namespace)
 		if err != nil {
-			closeScalers(ctx, scalersRes)
+			h.closeScalers(ctx, scalersRes)
 			return []scalers.Scaler{}, err
 		}
 
 		scaler, err := buildScaler(ctx, h.client, trigger.Type, config)
 		if err != nil {
-			closeScalers(ctx, scalersRes)
+			h.closeScalers(ctx, scalersRes)
 			h.recorder.Event(withTriggers, corev1.EventTypeWarning, eventreason.KEDAScalerFailed, err.Error())
 			return []scalers.Scaler{}, fmt.Errorf("error getting scaler for trigger #%d: %s", scalerIndex, err)
 		}
@@ -427,8 +506,18 @@ func asDuckWithTriggers(scalableObject interface{}) (*kedav1alpha1.WithTriggers,
 	}
 }
 
-func closeScalers(ctx context.Context, scalers []scalers.Scaler) {
+// closeScalers closes the scalers concurrently, so slow network teardowns don't add up
+func (h *scaleHandler) closeScalers(ctx context.Context, scalers []scalers.Scaler) {
+	wg := sync.WaitGroup{}
 	for _, scaler := range scalers {
-		defer scaler.Close(ctx)
+		scaler := scaler
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := scaler.Close(ctx); err != nil {
+				h.logger.Error(err, "Error closing scaler", "Scaler", fmt.Sprintf("%T", scaler))
+			}
+		}()
 	}
+	wg.Wait()
 }
@@ -95,6 +95,271 @@ func TestCheckScaledObjectFindFirstActiveIgnoringOthers(t *testing.T) {
 	assert.Equal(t, false, isError)
 }
 
+func TestCheckScaledObjectEvaluateAllTriggers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := mock_client.NewMockClient(ctrl)
+	recorder := record.NewFakeRecorder(1)
+
+	scaleHandler := &scaleHandler{
+		client:            client,
+		logger:            logf.Log.WithName("scalehandler"),
+		scaleLoopContexts: &sync.Map{},
+		scaleExecutor:     executor.NewScaleExecutor(client, nil, nil, recorder),
+		globalHTTPTimeout: 5 * time.Second,
+		recorder:          recorder,
+	}
+
+	activeScaler := mock_scalers.NewMockScaler(ctrl)
+	inactiveScaler := mock_scalers.NewMockScaler(ctrl)
+	secondActiveScaler := mock_scalers.NewMockScaler(ctrl)
+	scalers := []scalers.Scaler{activeScaler, inactiveScaler, secondActiveScaler}
+	scaledObject := &kedav1alpha1.ScaledObject{
+		Spec: kedav1alpha1.ScaledObjectSpec{
+			Advanced: &kedav1alpha1.AdvancedConfig{
+				EvaluateAllTriggers: true,
+			},
+		},
+	}
+
+	metricsSpecs := []v2beta2.MetricSpec{createMetricSpec(1)}
+
+	activeScaler.EXPECT().IsActive(gomock.Any()).Return(true, nil)
+	activeScaler.EXPECT().GetMetricSpecForScaling(gomock.Any()).Times(2).Return(metricsSpecs)
+	activeScaler.EXPECT().Close(gomock.Any())
+	inactiveScaler.EXPECT().IsActive(gomock.Any()).Return(false, nil)
+	inactiveScaler.EXPECT().Close(gomock.Any())
+	secondActiveScaler.EXPECT().IsActive(gomock.Any()).Return(true, nil)
+	secondActiveScaler.EXPECT().GetMetricSpecForScaling(gomock.Any()).Times(2).Return(metricsSpecs)
+	secondActiveScaler.EXPECT().Close(gomock.Any())
+
+	isActive, isError := scaleHandler.isScaledObjectActive(context.TODO(), scalers, scaledObject)
+
+	assert.Equal(t, true, isActive)
+	assert.Equal(t, false, isError)
+}
+
+func TestCheckScaledObjectCollectsScalerErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := mock_client.NewMockClient(ctrl)
+	recorder := record.NewFakeRecorder(2)
+
+	scaleHandler := &scaleHandler{
+		client:            client,
+		logger:            logf.Log.WithName("scalehandler"),
+		scaleLoopContexts: &sync.Map{},
+		scaleExecutor:     executor.NewScaleExecutor(client, nil, nil, recorder),
+		globalHTTPTimeout: 5 * time.Second,
+		recorder:          recorder,
+	}
+
+	failingScaler := mock_scalers.NewMockScaler(ctrl)
+	inactiveScaler := mock_scalers.NewMockScaler(ctrl)
+	secondFailingScaler := mock_scalers.NewMockScaler(ctrl)
+	scalers := []scalers.Scaler{failingScaler, inactiveScaler, secondFailingScaler}
+	scaledObject := &kedav1alpha1.ScaledObject{}
+
+	failingScaler.EXPECT().IsActive(gomock.Any()).Return(false, errors.New("first error"))
+	failingScaler.EXPECT().Close(gomock.Any())
+	inactiveScaler.EXPECT().IsActive(gomock.Any()).Return(false, nil)
+	inactiveScaler.EXPECT().Close(gomock.Any())
+	secondFailingScaler.EXPECT().IsActive(gomock.Any()).Return(false, errors.New("second error"))
+	secondFailingScaler.EXPECT().Close(gomock.Any())
+
+	isActive, scalerErrors := scaleHandler.isScaledObjectActiveWithErrors(context.TODO(), scalers, scaledObject)
+
+	assert.Equal(t, false, isActive)
+	assert.Len(t, scalerErrors, 2)
+	assert.EqualError(t, scalerErrors[0], "first error")
+	assert.NotContains(t, scalerErrors, 1)
+	assert.EqualError(t, scalerErrors[2], "second error")
+}
+
+func TestCheckScaledObjectScalerFailedEvent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := mock_client.NewMockClient(ctrl)
+	recorder := record.NewFakeRecorder(1)
+
+	scaleHandler := &scaleHandler{
+		client:            client,
+		logger:            logf.Log.WithName("scalehandler"),
+		scaleLoopContexts: &sync.Map{},
+		scaleExecutor:     executor.NewScaleExecutor(client, nil, nil, recorder),
+		globalHTTPTimeout: 5 * time.Second,
+		recorder:          recorder,
+	}
+
+	inactiveScaler := mock_scalers.NewMockScaler(ctrl)
+	failingScaler := mock_scalers.NewMockScaler(ctrl)
+	scalers := []scalers.Scaler{inactiveScaler, failingScaler}
+	scaledObject := &kedav1alpha1.ScaledObject{}
+
+	inactiveScaler.EXPECT().IsActive(gomock.Any()).Return(false, nil)
+	inactiveScaler.EXPECT().Close(gomock.Any())
+	failingScaler.EXPECT().IsActive(gomock.Any()).Return(false, errors.New("some error"))
+	failingScaler.EXPECT().Close(gomock.Any())
+
+	scaleHandler.isScaledObjectActive(context.TODO(), scalers, scaledObject)
+
+	assert.Equal(t, "Warning KEDAScalerFailed trigger #1 (*mock_scalers.MockScaler): some error", <-recorder.Events)
+}
+
+func TestCheckScaledObjectScalerTimeout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := mock_client.NewMockClient(ctrl)
+	recorder := record.NewFakeRecorder(1)
+
+	scaleHandler := &scaleHandler{
+		client:            client,
+		logger:            logf.Log.WithName("scalehandler"),
+		scaleLoopContexts: &sync.Map{},
+		scaleExecutor:     executor.NewScaleExecutor(client, nil, nil, recorder),
+		globalHTTPTimeout: 5 * time.Second,
+		recorder:          recorder,
+		scalerTimeout:     200 * time.Millisecond,
+	}
+
+	hungScaler := mock_scalers.NewMockScaler(ctrl)
+	activeScaler := mock_scalers.NewMockScaler(ctrl)
+	scalers := []scalers.Scaler{hungScaler, activeScaler}
+	scaledObject := &kedav1alpha1.ScaledObject{}
+
+	metricsSpecs := []v2beta2.MetricSpec{createMetricSpec(1)}
+
+	sawCancellation := make(chan struct{})
+	closed := make(chan struct{})
+	hungScaler.EXPECT().IsActive(gomock.Any()).DoAndReturn(func(ctx context.Context) (bool, error) {
+		<-ctx.Done()
+		close(sawCancellation)
+		return false, ctx.Err()
+	})
+	hungScaler.EXPECT().Close(gomock.Any()).DoAndReturn(func(context.Context) error {
+		select {
+		case <-sawCancellation:
+		default:
+			t.Error("Expected the scaler to be closed only after IsActive returned")
+		}
+		close(closed)
+		return nil
+	})
+	activeScaler.EXPECT().IsActive(gomock.Any()).DoAndReturn(func(context.Context) (bool, error) {
+		time.Sleep(50 * time.Millisecond)
+		return true, nil
+	})
+	activeScaler.EXPECT().GetMetricSpecForScaling(gomock.Any()).Times(2).Return(metricsSpecs)
+	activeScaler.EXPECT().Close(gomock.Any())
+
+	start := time.Now()
+	isActive, isError := scaleHandler.isScaledObjectActive(context.TODO(), scalers, scaledObject)
+
+	assert.Equal(t, true, isActive)
+	assert.Equal(t, true, isError)
+	assert.Less(t, int64(time.Since(start)), int64(time.Second))
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Error("Expected the hung scaler to see the cancellation and be closed")
+	}
+}
+
+func TestIsScalerActiveWithCanceledContext(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	scaleHandler := &scaleHandler{
+		logger:        logf.Log.WithName("scalehandler"),
+		scalerTimeout: time.Minute,
+	}
+
+	scaler := mock_scalers.NewMockScaler(ctrl)
+	scaler.EXPECT().IsActive(gomock.Any()).DoAndReturn(func(ctx context.Context) (bool, error) {
+		<-ctx.Done()
+		return false, ctx.Err()
+	})
+	closed := make(chan struct{})
+	scaler.EXPECT().Close(gomock.Any()).DoAndReturn(func(context.Context) error {
+		close(closed)
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := scaleHandler.isScalerActiveAndClose(ctx, scaler)
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.NotContains(t, err.Error(), "did not respond within")
+	<-closed
+}
+
+func TestBuildScalersWithCanceledContext(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := mock_client.NewMockClient(ctrl)
+	recorder := record.NewFakeRecorder(1)
+
+	scaleHandler := &scaleHandler{
+		client:            client,
+		logger:            logf.Log.WithName("scalehandler"),
+		scaleLoopContexts: &sync.Map{},
+		scaleExecutor:     executor.NewScaleExecutor(client, nil, nil, recorder),
+		globalHTTPTimeout: 5 * time.Second,
+		recorder:          recorder,
+	}
+	withTriggers := &kedav1alpha1.WithTriggers{
+		Spec: kedav1alpha1.WithTriggersSpec{
+			Triggers: []kedav1alpha1.ScaleTriggers{
+				{
+					Type: "cron",
+					Metadata: map[string]string{
+						"timezone":        "UTC",
+						"start":           "0 * * * *",
+						"end":             "30 * * * *",
+						"desiredReplicas": "1",
+					},
+				},
+			},
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+
+	scalers, err := scaleHandler.buildScalers(ctx, withTriggers, nil, "")
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, scalers)
+}
+
+func TestCloseScalersConcurrently(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := mock_client.NewMockClient(ctrl)
+	recorder := record.NewFakeRecorder(1)
+
+	scaleHandler := &scaleHandler{
+		client:            client,
+		logger:            logf.Log.WithName("scalehandler"),
+		scaleLoopContexts: &sync.Map{},
+		scaleExecutor:     executor.NewScaleExecutor(client, nil, nil, recorder),
+		globalHTTPTimeout: 5 * time.Second,
+		recorder:          recorder,
+	}
+
+	slowClose := func(context.Context) error {
+		time.Sleep(200 * time.Millisecond)
+		return nil
+	}
+	slowScaler := mock_scalers.NewMockScaler(ctrl)
+	secondSlowScaler := mock_scalers.NewMockScaler(ctrl)
+	failingScaler := mock_scalers.NewMockScaler(ctrl)
+	scalers := []scalers.Scaler{slowScaler, secondSlowScaler, failingScaler}
+
+	slowScaler.EXPECT().Close(gomock.Any()).DoAndReturn(slowClose)
+	secondSlowScaler.EXPECT().Close(gomock.Any()).DoAndReturn(slowClose)
+	failingScaler.EXPECT().Close(gomock.Any()).Return(errors.New("some error"))
+
+	start := time.Now()
+	scaleHandler.closeScalers(context.TODO(), scalers)
+
+	assert.Less(t, int64(time.Since(start)), int64(400*time.Millisecond))
+}
+
 func createMetricSpec(averageValue int) v2beta2.MetricSpec {
 	qty := resource.NewQuantity(int64(averageValue), resource.DecimalSI)
 	return v2beta2.MetricSpec{
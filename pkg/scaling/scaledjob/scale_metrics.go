@@ -3,6 +3,7 @@ package scaledjob
 import (
 	"context"
 	"fmt"
+	"sync"
 
 	"github.com/go-logr/logr"
 	"k8s.io/api/autoscaling/v2beta2"
@@ -13,6 +14,7 @@ import (
 	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
 	"github.com/kedacore/keda/v2/pkg/eventreason"
 	"github.com/kedacore/keda/v2/pkg/scalers"
+	kedautil "github.com/kedacore/keda/v2/pkg/util"
 )
 
 type scalerMetrics struct {
@@ -21,14 +23,37 @@ type scalerMetrics struct {
 	isActive    bool
 }
 
+// ScaledJobResult is the scale decision of a ScaledJob
+type ScaledJobResult struct {
+	IsActive    bool
+	QueueLength int64
+	MaxValue    int64
+	// ActiveTriggerCount is the number of active triggers, whatever the MultipleScalersCalculation
+	ActiveTriggerCount int
+}
+
 // GetScaleMetrics gets the metrics for decision making of scaling.
+//
+// Deprecated: use GetScaledJobResult.
 func GetScaleMetrics(ctx context.Context, scalers []scalers.Scaler, scaledJob *kedav1alpha1.ScaledJob, recorder record.EventRecorder) (bool, int64, int64) {
+	result := GetScaledJobResult(ctx, scalers, scaledJob, recorder)
+	return result.IsActive, result.QueueLength, result.MaxValue
+}
+
+// GetScaledJobResult gets the metrics of the scalers and combines them into the scale decision
+func GetScaledJobResult(ctx context.Context, scalers []scalers.Scaler, scaledJob *kedav1alpha1.ScaledJob, recorder record.EventRecorder) ScaledJobResult {
 	var queueLength int64
 	var maxValue int64
 	isActive := false
+	activeTriggerCount := 0
 
 	logger := logf.Log.WithName("scalemetrics")
 	scalersMetrics := getScalersMetrics(ctx, scalers, scaledJob, logger, recorder)
+	for _, metrics := range scalersMetrics {
+		if metrics.isActive {
+			activeTriggerCount++
+		}
+	}
 	switch scaledJob.Spec.ScalingStrategy.MultipleScalersCalculation {
 	case "min":
 		for _, metrics := range scalersMetrics {
@@ -42,18 +67,34 @@ func GetScaleMetrics(ctx context.Context, scalers []scalers.Scaler, scaledJob *k
 		queueLengthSum := int64(0)
 		maxValueSum := int64(0)
 		length := 0
+		// by default only the active scalers are averaged, includeInactiveInAverage
+		// also counts the inactive ones so a single hot queue doesn't over-scale
+		includeInactive := scaledJob.Spec.ScalingStrategy.IncludeInactiveInAverage
 		for _, metrics := range scalersMetrics {
 			if metrics.isActive {
+				isActive = true
+			}
+			if metrics.isActive || includeInactive {
 				queueLengthSum += metrics.queueLength
 				maxValueSum += metrics.maxValue
-				isActive = metrics.isActive
 				length++
 			}
 		}
 		if length != 0 {
-			queueLength = divideWithCeil(queueLengthSum, int64(length))
-			maxValue = divideWithCeil(maxValueSum, int64(length))
+			roundingMode := scaledJob.Spec.ScalingStrategy.ScalingRoundingMode
+			queueLength = divideWithRoundingMode(queueLengthSum, int64(length), roundingMode)
+			maxValue = divideWithRoundingMode(maxValueSum, int64(length), roundingMode)
+		}
+	case "count":
+		// scale on the number of active triggers rather than on their queue lengths,
+		// e.g. one job for each queue that currently has messages
+		for _, metrics := range scalersMetrics {
+			if metrics.isActive {
+				queueLength++
+				isActive = true
+			}
 		}
+		maxValue = queueLength
 	case "sum":
 		for _, metrics := range scalersMetrics {
 			if metrics.isActive {
@@ -71,114 +112,149 @@ func GetScaleMetrics(ctx context.Context, scalers []scalers.Scaler, scaledJob *k
 			}
 		}
 	}
-	maxValue = min(scaledJob.MaxReplicaCount(), maxValue)
+	maxValue = kedautil.MinInt64(scaledJob.MaxReplicaCount(), maxValue)
+	// an active ScaledJob never reports less than minReplicaCount, even when the
+	// calculation rounds down to 0
+	if isActive && maxValue < scaledJob.MinReplicaCount() {
+		maxValue = kedautil.MinInt64(scaledJob.MaxReplicaCount(), scaledJob.MinReplicaCount())
+	}
 	logger.V(1).WithValues("ScaledJob", scaledJob.Name).Info("Checking if ScaleJob scalers are active", "isActive", isActive, "maxValue", maxValue, "MultipleScalersCalculation", scaledJob.Spec.ScalingStrategy.MultipleScalersCalculation)
 
-	return isActive, queueLength, maxValue
+	return ScaledJobResult{
+		IsActive:           isActive,
+		QueueLength:        queueLength,
+		MaxValue:           maxValue,
+		ActiveTriggerCount: activeTriggerCount,
+	}
 }
 
 func getScalersMetrics(ctx context.Context, scalers []scalers.Scaler, scaledJob *kedav1alpha1.ScaledJob, logger logr.Logger, recorder record.EventRecorder) []scalerMetrics {
+	// the scalers are queried concurrently, the results keep the order of the scalers
+	results := make([]*scalerMetrics, len(scalers))
+	wg := sync.WaitGroup{}
+	for i, scaler := range scalers {
+		i, scaler := i, scaler
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = getScalerMetrics(ctx, i, scaler, scaledJob, logger, recorder)
+		}()
+	}
+	wg.Wait()
+
 	scalersMetrics := []scalerMetrics{}
+	for _, metrics := range results {
+		if metrics != nil {
+			scalersMetrics = append(scalersMetrics, *metrics)
+		}
+	}
+	return scalersMetrics
+}
 
-	for _, scaler := range scalers {
-		var queueLength int64
-		var targetAverageValue int64
-		isActive := false
-		maxValue := int64(0)
-		scalerType := fmt.Sprintf("%T:", scaler)
+// getScalerMetrics returns nil when the scaler is skipped or fails
+func getScalerMetrics(ctx context.Context, scalerIndex int, scaler scalers.Scaler, scaledJob *kedav1alpha1.ScaledJob, logger logr.Logger, recorder record.EventRecorder) *scalerMetrics {
+	var queueLength int64
+	var queueLengthMilli int64
+	var targetAverageMilliValue int64
+	isActive := false
+	maxValue := int64(0)
+	scalerType := fmt.Sprintf("%T:", scaler)
 
-		scalerLogger := logger.WithValues("ScaledJob", scaledJob.Name, "Scaler", scalerType)
+	scalerLogger := logger.WithValues("ScaledJob", scaledJob.Name, "Scaler", scalerType)
 
-		metricSpecs := scaler.GetMetricSpecForScaling(ctx)
+	metricSpecs := scaler.GetMetricSpecForScaling(ctx)
 
-		// skip scaler that doesn't return any metric specs (usually External scaler with incorrect metadata)
-		// or skip cpu/memory resource scaler
-		if len(metricSpecs) < 1 || metricSpecs[0].External == nil {
-			continue
-		}
+	// skip scaler that doesn't return any metric specs (usually External scaler with incorrect metadata)
+	if len(metricSpecs) < 1 {
+		scalerLogger.V(1).Info("Skipping scaler without metric specs")
+		return nil
+	}
 
-		isTriggerActive, err := scaler.IsActive(ctx)
-		if err != nil {
-			scalerLogger.V(1).Info("Error getting scaler.IsActive, but continue", "Error", err)
-			recorder.Event(scaledJob, corev1.EventTypeWarning, eventreason.KEDAScalerFailed, err.Error())
-			scaler.Close(ctx)
-			continue
-		}
+	// skip cpu/memory resource scaler, the Jobs are not scaled by an HPA
+	if metricSpecs[0].External == nil {
+		scalerLogger.V(1).Info("Skipping resource scaler, cpu and memory triggers are not supported for ScaledJobs")
+		return nil
+	}
 
-		targetAverageValue = getTargetAverageValue(metricSpecs)
+	isTriggerActive, err := scaler.IsActive(ctx)
+	if err != nil {
+		scalerLogger.V(1).Info("Error getting scaler.IsActive, but continue", "Error", err)
+		recorder.Event(scaledJob, corev1.EventTypeWarning, eventreason.KEDAScalerFailed, scalers.ScalerFailedMessage(scalerIndex, scaler, err))
+		scaler.Close(ctx)
+		return nil
+	}
 
-		metrics, err := scaler.GetMetrics(ctx, "queueLength", nil)
-		if err != nil {
-			scalerLogger.V(1).Info("Error getting scaler metrics, but continue", "Error", err)
-			recorder.Event(scaledJob, corev1.EventTypeWarning, eventreason.KEDAScalerFailed, err.Error())
-			scaler.Close(ctx)
-			continue
-		}
+	targetAverageMilliValue = getTargetAverageMilliValue(metricSpecs)
 
-		var metricValue int64
+	metrics, err := scaler.GetMetrics(ctx, "queueLength", nil)
+	if err != nil {
+		scalerLogger.V(1).Info("Error getting scaler metrics, but continue", "Error", err)
+		recorder.Event(scaledJob, corev1.EventTypeWarning, eventreason.KEDAScalerFailed, scalers.ScalerFailedMessage(scalerIndex, scaler, err))
+		scaler.Close(ctx)
+		return nil
+	}
 
-		for _, m := range metrics {
-			if m.MetricName == "queueLength" {
-				metricValue, _ = m.Value.AsInt64()
-				queueLength += metricValue
-			}
+	// the values are read in milli units, scalers report fractions like 0.5 with the milli scale
+	// and AsInt64 doesn't convert those quantities even when they are whole
+	for _, m := range metrics {
+		if m.MetricName == "queueLength" {
+			queueLengthMilli += m.Value.MilliValue()
 		}
-		scalerLogger.V(1).Info("Scaler Metric value", "isTriggerActive", isTriggerActive, "queueLength", queueLength, "targetAverageValue", targetAverageValue)
+	}
+	queueLength = kedautil.DivideWithCeil(queueLengthMilli, 1000)
+	scalerLogger.V(1).Info("Scaler Metric value", "isTriggerActive", isTriggerActive, "queueLength", queueLength, "targetAverageMilliValue", targetAverageMilliValue)
 
-		scaler.Close(ctx)
+	scaler.Close(ctx)
 
-		if isTriggerActive {
-			isActive = true
-		}
+	// a backlog up to activationQueueLength doesn't keep the jobs alive, 0 keeps
+	// the trigger's own activity
+	activationQueueLength := scaledJob.Spec.ScalingStrategy.ActivationQueueLength
+	if isTriggerActive && (activationQueueLength == 0 || queueLength > activationQueueLength) {
+		isActive = true
+	}
 
-		if targetAverageValue != 0 {
-			maxValue = min(scaledJob.MaxReplicaCount(), divideWithCeil(queueLength, targetAverageValue))
-		}
-		scalersMetrics = append(scalersMetrics, scalerMetrics{
-			queueLength: queueLength,
-			maxValue:    maxValue,
-			isActive:    isActive,
-		})
+	if targetAverageMilliValue != 0 {
+		maxValue = kedautil.MinInt64(scaledJob.MaxReplicaCount(), divideWithRoundingMode(queueLengthMilli, targetAverageMilliValue, scaledJob.Spec.ScalingStrategy.ScalingRoundingMode))
+	}
+	return &scalerMetrics{
+		queueLength: queueLength,
+		maxValue:    maxValue,
+		isActive:    isActive,
+	}
+}
+
+// divideWithRoundingMode rounds with the scalingRoundingMode of the ScaledJob, one of ceil, floor or round.
+// It defaults to ceil so a partially filled job is still started, unknown modes also fall back to ceil
+func divideWithRoundingMode(x, y int64, roundingMode string) int64 {
+	switch roundingMode {
+	case "floor":
+		return kedautil.DivideWithFloor(x, y)
+	case "round":
+		return kedautil.DivideWithRound(x, y)
+	case "", "ceil":
+		return kedautil.DivideWithCeil(x, y)
+	default:
+		// the CRD rejects unknown modes, this is called for every division so keep it out of the default log
+		logf.Log.WithName("scalemetrics").V(1).Info("Unknown scalingRoundingMode, using ceil", "scalingRoundingMode", roundingMode)
+		return kedautil.DivideWithCeil(x, y)
 	}
-	return scalersMetrics
 }
 
-func getTargetAverageValue(metricSpecs []v2beta2.MetricSpec) int64 {
-	var targetAverageValue int64
-	var metricValue int64
-	var flag bool
+// getTargetAverageMilliValue returns the average of the targets in milli units, like the queue length.
+// Triggers with a Value target type don't set the AverageValue, their Value is used instead
+func getTargetAverageMilliValue(metricSpecs []v2beta2.MetricSpec) int64 {
+	var targetAverageMilliValue int64
 	for _, metric := range metricSpecs {
-		if metric.External.Target.AverageValue == nil {
-			metricValue = 0
-		} else {
-			metricValue, flag = metric.External.Target.AverageValue.AsInt64()
-			if !flag {
-				metricValue = 0
-			}
+		switch {
+		case metric.External.Target.AverageValue != nil:
+			targetAverageMilliValue += metric.External.Target.AverageValue.MilliValue()
+		case metric.External.Target.Value != nil:
+			targetAverageMilliValue += metric.External.Target.Value.MilliValue()
 		}
-
-		targetAverageValue += metricValue
 	}
 	count := int64(len(metricSpecs))
 	if count != 0 {
-		return targetAverageValue / count
+		return targetAverageMilliValue / count
 	}
 	return 0
 }
-
-func divideWithCeil(x, y int64) int64 {
-	ans := x / y
-	reminder := x % y
-	if reminder != 0 {
-		return ans + 1
-	}
-	return ans
-}
-
-// Min function for int64
-func min(x, y int64) int64 {
-	if x > y {
-		return y
-	}
-	return x
-}
@@ -2,15 +2,21 @@ package scaledjob
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"testing"
+	"time"
 
+	"github.com/go-logr/logr"
 	"github.com/go-playground/assert/v2"
 	"github.com/golang/mock/gomock"
 	"k8s.io/api/autoscaling/v2beta2"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/metrics/pkg/apis/external_metrics"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
 
 	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
 	mock_scalers "github.com/kedacore/keda/v2/pkg/mock/mock_scaler"
@@ -20,23 +26,23 @@ import (
 func TestTargetAverageValue(t *testing.T) {
 	// count = 0
 	specs := []v2beta2.MetricSpec{}
-	targetAverageValue := getTargetAverageValue(specs)
+	targetAverageValue := getTargetAverageMilliValue(specs)
 	assert.Equal(t, int64(0), targetAverageValue)
 	// 1 1
 	specs = []v2beta2.MetricSpec{
 		createMetricSpec(1),
 		createMetricSpec(1),
 	}
-	targetAverageValue = getTargetAverageValue(specs)
-	assert.Equal(t, int64(1), targetAverageValue)
+	targetAverageValue = getTargetAverageMilliValue(specs)
+	assert.Equal(t, int64(1000), targetAverageValue)
 	// 5 5 3
 	specs = []v2beta2.MetricSpec{
 		createMetricSpec(5),
 		createMetricSpec(5),
 		createMetricSpec(3),
 	}
-	targetAverageValue = getTargetAverageValue(specs)
-	assert.Equal(t, int64(4), targetAverageValue)
+	targetAverageValue = getTargetAverageMilliValue(specs)
+	assert.Equal(t, int64(4333), targetAverageValue)
 
 	// 5 5 4
 	specs = []v2beta2.MetricSpec{
@@ -44,8 +50,21 @@ func TestTargetAverageValue(t *testing.T) {
 		createMetricSpec(5),
 		createMetricSpec(3),
 	}
-	targetAverageValue = getTargetAverageValue(specs)
-	assert.Equal(t, int64(4), targetAverageValue)
+	targetAverageValue = getTargetAverageMilliValue(specs)
+	assert.Equal(t, int64(4333), targetAverageValue)
+
+	// 5 (Value) 3
+	valueSpec := createMetricSpec(0)
+	valueSpec.External.Target = v2beta2.MetricTarget{
+		Type:  v2beta2.ValueMetricType,
+		Value: resource.NewQuantity(5, resource.DecimalSI),
+	}
+	specs = []v2beta2.MetricSpec{
+		valueSpec,
+		createMetricSpec(3),
+	}
+	targetAverageValue = getTargetAverageMilliValue(specs)
+	assert.Equal(t, int64(4000), targetAverageValue)
 }
 
 func createMetricSpec(averageValue int) v2beta2.MetricSpec {
@@ -59,6 +78,12 @@ func createMetricSpec(averageValue int) v2beta2.MetricSpec {
 	}
 }
 
+func createMilliMetricSpec(averageMilliValue int64) v2beta2.MetricSpec {
+	metricSpec := createMetricSpec(0)
+	metricSpec.External.Target.AverageValue = resource.NewMilliQuantity(averageMilliValue, resource.DecimalSI)
+	return metricSpec
+}
+
 func TestIsScaledJobActive(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	recorder := record.NewFakeRecorder(1)
@@ -92,6 +117,9 @@ func TestIsScaledJobActive(t *testing.T) {
 		newScalerTestData(100, "avg", 20, 1, true, 10, 2, true, 5, 3, true, 7, 4, false, true, 12, 9),
 		newScalerTestData(100, "sum", 20, 1, true, 10, 2, true, 5, 3, true, 7, 4, false, true, 35, 27),
 		newScalerTestData(25, "sum", 20, 1, true, 10, 2, true, 5, 3, true, 7, 4, false, true, 35, 25),
+		newScalerTestData(100, "count", 20, 1, true, 10, 2, true, 5, 3, true, 7, 4, false, true, 3, 3),
+		newScalerTestData(2, "count", 20, 1, true, 10, 2, true, 5, 3, true, 7, 4, false, true, 3, 2),
+		newScalerTestData(100, "count", 20, 1, false, 10, 2, false, 5, 3, false, 7, 4, false, false, 0, 0),
 	}
 
 	for index, scalerTestData := range scalerTestDatam {
@@ -111,6 +139,263 @@ func TestIsScaledJobActive(t *testing.T) {
 	}
 }
 
+func TestGetScaledJobResult(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	recorder := record.NewFakeRecorder(1)
+
+	for _, calculation := range []string{"max", "min", "avg", "sum", "count"} {
+		scaledJob := createScaledObject(100, calculation)
+		createScalers := func() []scalers.Scaler {
+			return []scalers.Scaler{
+				createScaler(ctrl, int64(20), int32(1), true),
+				createScaler(ctrl, int64(10), int32(2), true),
+				createScaler(ctrl, int64(7), int32(4), false),
+			}
+		}
+
+		isActive, queueLength, maxValue := GetScaleMetrics(context.TODO(), createScalers(), scaledJob, recorder)
+		result := GetScaledJobResult(context.TODO(), createScalers(), scaledJob, recorder)
+		assert.Equal(t, isActive, result.IsActive)
+		assert.Equal(t, queueLength, result.QueueLength)
+		assert.Equal(t, maxValue, result.MaxValue)
+		assert.Equal(t, 2, result.ActiveTriggerCount)
+	}
+}
+
+func TestGetScaledJobResultFractionalQuantity(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	recorder := record.NewFakeRecorder(1)
+
+	// cloudwatch reports 1.5 messages per second with a target of 0.5 as milli quantities
+	metricName := "queueLength"
+	scaler := mock_scalers.NewMockScaler(ctrl)
+	metrics := []external_metrics.ExternalMetricValue{
+		{
+			MetricName: metricName,
+			Value:      *resource.NewMilliQuantity(1500, resource.DecimalSI),
+		},
+	}
+	scaler.EXPECT().IsActive(gomock.Any()).Return(true, nil)
+	scaler.EXPECT().GetMetricSpecForScaling(gomock.Any()).Return([]v2beta2.MetricSpec{createMilliMetricSpec(500)})
+	scaler.EXPECT().GetMetrics(gomock.Any(), metricName, nil).Return(metrics, nil)
+	scaler.EXPECT().Close(gomock.Any())
+
+	result := GetScaledJobResult(context.TODO(), []scalers.Scaler{scaler}, createScaledObject(100, "max"), recorder)
+	assert.Equal(t, true, result.IsActive)
+	assert.Equal(t, int64(2), result.QueueLength)
+	assert.Equal(t, int64(3), result.MaxValue)
+	assert.Equal(t, 1, result.ActiveTriggerCount)
+}
+
+func TestIsScaledJobActiveMinReplicaCount(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	recorder := record.NewFakeRecorder(1)
+
+	for _, multipleScalersCalculation := range []string{"avg", "min"} {
+		scaledJob := createScaledObject(10, multipleScalersCalculation)
+		minReplicaCount := int32(2)
+		scaledJob.Spec.MinReplicaCount = &minReplicaCount
+
+		// active scalers whose queue lengths round down to 0
+		scalerList := []scalers.Scaler{
+			createScaler(ctrl, int64(0), int32(2), true),
+			createScaler(ctrl, int64(0), int32(5), true),
+		}
+		isActive, _, maxValue := GetScaleMetrics(context.TODO(), scalerList, scaledJob, recorder)
+		assert.Equal(t, true, isActive)
+		assert.Equal(t, int64(2), maxValue)
+
+		// the floor is not applied to an inactive ScaledJob
+		scalerList = []scalers.Scaler{
+			createScaler(ctrl, int64(0), int32(2), false),
+		}
+		isActive, _, maxValue = GetScaleMetrics(context.TODO(), scalerList, scaledJob, recorder)
+		assert.Equal(t, false, isActive)
+		assert.Equal(t, int64(0), maxValue)
+	}
+}
+
+func TestIsScaledJobActiveAvgIncludeInactive(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	recorder := record.NewFakeRecorder(1)
+
+	tests := []struct {
+		includeInactive bool
+		queueLength     int64
+		maxValue        int64
+	}{
+		{false, 20, 20},
+		{true, 7, 7},
+	}
+	for _, test := range tests {
+		scaledJob := createScaledObject(100, "avg")
+		scaledJob.Spec.ScalingStrategy.IncludeInactiveInAverage = test.includeInactive
+		scalers := []scalers.Scaler{
+			createScaler(ctrl, int64(20), int32(1), true),
+			createScaler(ctrl, int64(0), int32(1), false),
+			createScaler(ctrl, int64(0), int32(1), false),
+		}
+
+		isActive, queueLength, maxValue := GetScaleMetrics(context.TODO(), scalers, scaledJob, recorder)
+		assert.Equal(t, true, isActive)
+		assert.Equal(t, test.queueLength, queueLength)
+		assert.Equal(t, test.maxValue, maxValue)
+	}
+}
+
+func TestIsScaledJobActiveScalingRoundingMode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	recorder := record.NewFakeRecorder(1)
+
+	tests := []struct {
+		roundingMode string
+		queueLength  int64
+		maxValue     int64
+	}{
+		{"", 21, 3},
+		{"ceil", 20, 2},
+		{"ceil", 21, 3},
+		{"floor", 21, 2},
+		{"floor", 29, 2},
+		{"floor", 30, 3},
+		{"round", 24, 2},
+		{"round", 25, 3},
+		{"round", 30, 3},
+		{"unknown", 21, 3},
+	}
+	for _, test := range tests {
+		scaledJob := createScaledObject(100, "max")
+		scaledJob.Spec.ScalingStrategy.ScalingRoundingMode = test.roundingMode
+		scalers := []scalers.Scaler{
+			createScaler(ctrl, test.queueLength, int32(10), true),
+		}
+
+		_, _, maxValue := GetScaleMetrics(context.TODO(), scalers, scaledJob, recorder)
+		assert.Equal(t, test.maxValue, maxValue)
+	}
+}
+
+func TestIsScaledJobActiveActivationQueueLength(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	recorder := record.NewFakeRecorder(1)
+
+	tests := []struct {
+		activationQueueLength int64
+		queueLength           int64
+		isActive              bool
+	}{
+		{0, 1, true},
+		{0, 3, true},
+		{5, 3, false},
+		{5, 5, false},
+		{5, 6, true},
+	}
+	for _, test := range tests {
+		scaledJob := createScaledObject(100, "max")
+		scaledJob.Spec.ScalingStrategy.ActivationQueueLength = test.activationQueueLength
+		scalers := []scalers.Scaler{
+			createScaler(ctrl, test.queueLength, int32(1), true),
+		}
+
+		isActive, _, _ := GetScaleMetrics(context.TODO(), scalers, scaledJob, recorder)
+		assert.Equal(t, test.isActive, isActive)
+	}
+}
+
+func TestIsScaledJobActiveMilliQuantity(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	recorder := record.NewFakeRecorder(1)
+
+	// scalers like cloudwatch report the values with the milli scale
+	metricName := "queueLength"
+	scaler := mock_scalers.NewMockScaler(ctrl)
+	metricsSpecs := []v2beta2.MetricSpec{createMilliMetricSpec(2000)}
+	metrics := []external_metrics.ExternalMetricValue{
+		{
+			MetricName: metricName,
+			Value:      *resource.NewMilliQuantity(7000, resource.DecimalSI),
+		},
+	}
+	scaler.EXPECT().IsActive(gomock.Any()).Return(true, nil)
+	scaler.EXPECT().GetMetricSpecForScaling(gomock.Any()).Return(metricsSpecs)
+	scaler.EXPECT().GetMetrics(gomock.Any(), metricName, nil).Return(metrics, nil)
+	scaler.EXPECT().Close(gomock.Any())
+
+	isActive, queueLength, maxValue := GetScaleMetrics(context.TODO(), []scalers.Scaler{scaler}, createScaledObject(100, "max"), recorder)
+	assert.Equal(t, true, isActive)
+	assert.Equal(t, int64(7), queueLength)
+	assert.Equal(t, int64(4), maxValue)
+}
+
+func TestIsScaledJobActiveScalerFailedEvent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	recorder := record.NewFakeRecorder(1)
+
+	failingScaler := mock_scalers.NewMockScaler(ctrl)
+	failingScaler.EXPECT().GetMetricSpecForScaling(gomock.Any()).Return([]v2beta2.MetricSpec{createMetricSpec(1)})
+	failingScaler.EXPECT().IsActive(gomock.Any()).Return(false, errors.New("some error"))
+	failingScaler.EXPECT().Close(gomock.Any())
+
+	scaledJob := createScaledObject(100, "max")
+	scalers := []scalers.Scaler{
+		createScaler(ctrl, int64(10), int32(2), true),
+		failingScaler,
+	}
+
+	GetScaleMetrics(context.TODO(), scalers, scaledJob, recorder)
+	assert.Equal(t, "Warning KEDAScalerFailed trigger #1 (*mock_scalers.MockScaler): some error", <-recorder.Events)
+}
+
+func TestIsScaledJobActiveSkipsResourceScalers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	recorder := record.NewFakeRecorder(1)
+
+	resourceScaler := mock_scalers.NewMockScaler(ctrl)
+	resourceScaler.EXPECT().GetMetricSpecForScaling(gomock.Any()).Return([]v2beta2.MetricSpec{
+		{
+			Type: v2beta2.ResourceMetricSourceType,
+			Resource: &v2beta2.ResourceMetricSource{
+				Name: corev1.ResourceCPU,
+			},
+		},
+	}).Times(2)
+
+	scaledJob := createScaledObject(100, "sum")
+	scalers := []scalers.Scaler{
+		createScaler(ctrl, int64(10), int32(2), true),
+		resourceScaler,
+	}
+
+	var lines []string
+	metrics := getScalerMetrics(context.TODO(), 1, resourceScaler, scaledJob, recordingLogger{lines: &lines}, recorder)
+	assert.Equal(t, true, metrics == nil)
+	assert.Equal(t, []string{"Skipping resource scaler, cpu and memory triggers are not supported for ScaledJobs"}, lines)
+
+	isActive, queueLength, maxValue := GetScaleMetrics(context.TODO(), scalers, scaledJob, recorder)
+	assert.Equal(t, true, isActive)
+	assert.Equal(t, int64(10), queueLength)
+	assert.Equal(t, int64(5), maxValue)
+}
+
+// recordingLogger keeps the messages logged at any verbosity
+type recordingLogger struct {
+	lines *[]string
+}
+
+func (l recordingLogger) Enabled() bool { return true }
+
+func (l recordingLogger) Info(msg string, keysAndValues ...interface{}) {
+	*l.lines = append(*l.lines, msg)
+}
+
+func (l recordingLogger) Error(err error, msg string, keysAndValues ...interface{}) {
+	*l.lines = append(*l.lines, msg)
+}
+
+func (l recordingLogger) V(level int) logr.Logger                             { return l }
+func (l recordingLogger) WithValues(keysAndValues ...interface{}) logr.Logger { return l }
+func (l recordingLogger) WithName(name string) logr.Logger                    { return l }
+
 func newScalerTestData(
 	maxReplicaCount int,
 	multipleScalersCalculation string,
@@ -204,3 +489,56 @@ func createScaler(ctrl *gomock.Controller, queueLength int64, averageValue int32
 	scaler.EXPECT().Close(gomock.Any())
 	return scaler
 }
+
+// slowScaler simulates a scaler backed by a remote call
+type slowScaler struct {
+	queueLength int64
+	latency     time.Duration
+}
+
+func (s *slowScaler) GetMetrics(ctx context.Context, metricName string, metricSelector labels.Selector) ([]external_metrics.ExternalMetricValue, error) {
+	time.Sleep(s.latency)
+	return []external_metrics.ExternalMetricValue{
+		{
+			MetricName: metricName,
+			Value:      *resource.NewQuantity(s.queueLength, resource.DecimalSI),
+		},
+	}, nil
+}
+
+func (s *slowScaler) GetMetricSpecForScaling(ctx context.Context) []v2beta2.MetricSpec {
+	return []v2beta2.MetricSpec{createMetricSpec(1)}
+}
+
+func (s *slowScaler) IsActive(ctx context.Context) (bool, error) {
+	time.Sleep(s.latency)
+	return s.queueLength > 0, nil
+}
+
+func (s *slowScaler) Close(ctx context.Context) error {
+	return nil
+}
+
+func BenchmarkGetScaleMetrics(b *testing.B) {
+	recorder := record.NewFakeRecorder(1)
+	scaledJob := createScaledObject(100, "sum")
+	slowScalers := make([]scalers.Scaler, 20)
+	for i := range slowScalers {
+		slowScalers[i] = &slowScaler{queueLength: int64(i), latency: 10 * time.Millisecond}
+	}
+
+	// serial is the baseline of querying the scalers one after the other
+	b.Run("serial", func(b *testing.B) {
+		logger := logf.Log.WithName("scalemetrics")
+		for i := 0; i < b.N; i++ {
+			for scalerIndex, scaler := range slowScalers {
+				getScalerMetrics(context.TODO(), scalerIndex, scaler, scaledJob, logger, recorder)
+			}
+		}
+	})
+	b.Run("concurrent", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			GetScaleMetrics(context.TODO(), slowScalers, scaledJob, recorder)
+		}
+	})
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2021 The KEDA Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+// MinInt64 returns the smaller of x or y
+func MinInt64(x, y int64) int64 {
+	if x > y {
+		return y
+	}
+	return x
+}
+
+// DivideWithCeil divides x by y and rounds the result up, it returns 0 when y is 0
+func DivideWithCeil(x, y int64) int64 {
+	if y == 0 {
+		return 0
+	}
+	ans := x / y
+	reminder := x % y
+	// the integer division truncates towards zero, so only positive results need to be rounded up
+	if reminder != 0 && (x < 0) == (y < 0) {
+		return ans + 1
+	}
+	return ans
+}
+
+// DivideWithFloor divides x by y and rounds the result down, it returns 0 when y is 0
+func DivideWithFloor(x, y int64) int64 {
+	if y == 0 {
+		return 0
+	}
+	ans := x / y
+	reminder := x % y
+	// the integer division truncates towards zero, so only negative results need to be rounded down
+	if reminder != 0 && (x < 0) != (y < 0) {
+		return ans - 1
+	}
+	return ans
+}
+
+// DivideWithRound divides x by y and rounds the result to the nearest integer, halves are
+// rounded up. It returns 0 when y is 0
+func DivideWithRound(x, y int64) int64 {
+	if y < 0 {
+		x, y = -x, -y
+	}
+	return DivideWithFloor(2*x+y, 2*y)
+}
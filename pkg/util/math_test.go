@@ -0,0 +1,104 @@
+/*
+Copyright 2021 The KEDA Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"testing"
+)
+
+type mathTestData struct {
+	comment  string
+	x        int64
+	y        int64
+	expected int64
+}
+
+var minInt64TestData = []mathTestData{
+	{"x is smaller", 1, 2, 1},
+	{"y is smaller", 5, 3, 3},
+	{"equal values", 4, 4, 4},
+	{"negative operands", -7, -2, -7},
+	{"mixed operands", -1, 1, -1},
+}
+
+var divideWithCeilTestData = []mathTestData{
+	{"exact division", 10, 2, 5},
+	{"rounds up", 11, 2, 6},
+	{"dividend smaller than divisor", 1, 3, 1},
+	{"zero dividend", 0, 3, 0},
+	{"negative dividend", -7, 2, -3},
+	{"negative divisor", 7, -2, -3},
+	{"negative operands", -7, -2, 4},
+	{"zero divisor", 7, 0, 0},
+	{"zero dividend and divisor", 0, 0, 0},
+}
+
+var divideWithFloorTestData = []mathTestData{
+	{"exact division", 10, 2, 5},
+	{"rounds down", 11, 2, 5},
+	{"dividend smaller than divisor", 1, 3, 0},
+	{"zero dividend", 0, 3, 0},
+	{"negative dividend", -7, 2, -4},
+	{"negative divisor", 7, -2, -4},
+	{"negative operands", -7, -2, 3},
+	{"zero divisor", 7, 0, 0},
+}
+
+var divideWithRoundTestData = []mathTestData{
+	{"exact division", 10, 2, 5},
+	{"rounds half up", 11, 2, 6},
+	{"rounds down below half", 10, 3, 3},
+	{"rounds up above half", 11, 3, 4},
+	{"dividend smaller than half the divisor", 1, 3, 0},
+	{"zero dividend", 0, 3, 0},
+	{"negative dividend", -10, 3, -3},
+	{"negative divisor", 11, -3, -4},
+	{"negative operands", -11, -3, 4},
+	{"zero divisor", 7, 0, 0},
+}
+
+func TestMinInt64(t *testing.T) {
+	for _, testData := range minInt64TestData {
+		if result := MinInt64(testData.x, testData.y); result != testData.expected {
+			t.Errorf("%s: expected %d but got %d", testData.comment, testData.expected, result)
+		}
+	}
+}
+
+func TestDivideWithCeil(t *testing.T) {
+	for _, testData := range divideWithCeilTestData {
+		if result := DivideWithCeil(testData.x, testData.y); result != testData.expected {
+			t.Errorf("%s: expected %d but got %d", testData.comment, testData.expected, result)
+		}
+	}
+}
+
+func TestDivideWithFloor(t *testing.T) {
+	for _, testData := range divideWithFloorTestData {
+		if result := DivideWithFloor(testData.x, testData.y); result != testData.expected {
+			t.Errorf("%s: expected %d but got %d", testData.comment, testData.expected, result)
+		}
+	}
+}
+
+func TestDivideWithRound(t *testing.T) {
+	for _, testData := range divideWithRoundTestData {
+		if result := DivideWithRound(testData.x, testData.y); result != testData.expected {
+			t.Errorf("%s: expected %d but got %d", testData.comment, testData.expected, result)
+		}
+	}
+}